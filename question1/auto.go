@@ -0,0 +1,33 @@
+package main
+
+import "runtime"
+
+// elementsPerWorker is roughly how many numbers it takes for an extra goroutine to pay off
+const elementsPerWorker = 100_000
+
+// autoWorkers picks the worker count for n numbers: one per elementsPerWorker numbers,
+// at least 1 and at most runtime.NumCPU()
+func autoWorkers(n int) int {
+	return max(1, min(n/elementsPerWorker, runtime.NumCPU()))
+}
+
+// SumEvenAuto sums the even numbers with a worker count chosen by autoWorkers, so callers don't
+// have to tune it. Slices too small for a second worker are summed sequentially without goroutines
+func SumEvenAuto(numbers []int) int {
+	workers := autoWorkers(len(numbers))
+	if workers == 1 {
+		return sumEvenSequential(numbers)
+	}
+	return sumEvenNumbersConcurrent(numbers, workers)
+}
+
+// sumEvenSequential sums the even numbers in a single loop
+func sumEvenSequential(numbers []int) int {
+	sum := 0
+	for _, num := range numbers {
+		if num%2 == 0 {
+			sum += num
+		}
+	}
+	return sum
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestAutoWorkers tests that the heuristic stays between 1 and NumCPU and never exceeds the element count
+func TestAutoWorkers(t *testing.T) {
+	for _, n := range []int{0, 1, 10, elementsPerWorker - 1, elementsPerWorker, 3 * elementsPerWorker, 1 << 30} {
+		workers := autoWorkers(n)
+		if workers < 1 || workers > runtime.NumCPU() {
+			t.Errorf("n=%d: expected 1..%d workers, got %d", n, runtime.NumCPU(), workers)
+		}
+		if n > 0 && workers > n {
+			t.Errorf("n=%d: %d workers is more than the elements", n, workers)
+		}
+		if n < 2*elementsPerWorker && workers != 1 {
+			t.Errorf("n=%d: expected a sequential sum, got %d workers", n, workers)
+		}
+	}
+}
+
+// TestSumEvenAuto tests the sum against the sequential result across slice sizes
+func TestSumEvenAuto(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 1000, elementsPerWorker, 4*elementsPerWorker + 3} {
+		numbers := make([]int, n)
+		for i := range numbers {
+			numbers[i] = i + 1
+		}
+		// 2 + 4 + ... + 2k with k = n/2
+		k := n / 2
+		if sum := SumEvenAuto(numbers); sum != k*(k+1) {
+			t.Errorf("n=%d: expected %d, got %d", n, k*(k+1), sum)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Option configures SumEvenTransform
+type Option func(*options)
+
+// options holds the settings applied by Option values
+type options struct {
+	attempts int           // total tries per unit, at least 1
+	backoff  time.Duration // delay before the first retry, doubled after each failure
+}
+
+// WithRetry makes each worker try a failing unit up to attempts times, waiting backoff before the
+// first retry and doubling the wait after every further failure, before surfacing the error
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.attempts = attempts
+		o.backoff = backoff
+	}
+}
+
+// retry calls fn until it succeeds or the attempts are used up, returning the last error.
+// The wait between attempts is cut short when ctx is done
+func (o options) retry(ctx context.Context, fn func() (int, error)) (int, error) {
+	delay := o.backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		var result int
+		if result, err = fn(); err == nil {
+			return result, nil
+		}
+		if attempt >= o.attempts {
+			return 0, err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return 0, err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// SumEvenTransform is sumEvenNumbersConcurrent with a fallible transform: every number is passed
+// through transform and the even results are summed. The first error is returned right away
+// and stops the remaining workers at their next unit
+func SumEvenTransform(numbers []int, numWorkers int, transform func(int) (int, error), opts ...Option) (int, error) {
+	return SumEvenTransformContext(context.Background(), numbers, numWorkers,
+		func(_ context.Context, n int) (int, error) { return transform(n) }, opts...)
+}
+
+// SumEvenTransformContext is SumEvenTransform with a context passed to transform. The collector
+// returns as soon as the first worker error arrives or ctx is done, then cancels the context
+// given to the workers so they stop at their next unit. The result and error channels have room
+// for one send per worker, so workers that finish after the collector returned never block
+func SumEvenTransformContext(ctx context.Context, numbers []int, numWorkers int, transform func(context.Context, int) (int, error), opts ...Option) (int, error) {
+	o := options{attempts: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.attempts < 1 {
+		o.attempts = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := chunkRanges(len(numbers), numWorkers)
+	results := make(chan int, len(chunks))
+	errs := make(chan error, len(chunks))
+
+	for _, c := range chunks {
+		go func(c chunk) {
+			sum := 0
+			for _, num := range numbers[c.start:c.end] {
+				if ctx.Err() != nil {
+					return // the collector has already returned
+				}
+
+				num := num
+				value, err := o.retry(ctx, func() (int, error) { return transform(ctx, num) })
+				if err != nil {
+					errs <- err
+					return
+				}
+				if value%2 == 0 {
+					sum += value
+				}
+			}
+			results <- sum
+		}(c)
+	}
+
+	total := 0
+	for pending := len(chunks); pending > 0; pending-- {
+		select {
+		case partial := <-results:
+			total += partial
+		case err := <-errs:
+			return 0, err
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+	return total, nil
+}
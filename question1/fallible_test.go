@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyIdentity returns a transform that fails its first failures calls, then returns the input
+func flakyIdentity(failures int64) (func(int) (int, error), *atomic.Int64) {
+	var calls atomic.Int64
+	return func(n int) (int, error) {
+		if calls.Add(1) <= failures {
+			return 0, errors.New("transient failure")
+		}
+		return n, nil
+	}, &calls
+}
+
+// TestSumEvenTransform_Retry tests that transient failures are retried and the sum stays correct
+func TestSumEvenTransform_Retry(t *testing.T) {
+	numbers := make([]int, 100)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+
+	// A single worker makes the two failures hit the same unit
+	transform, calls := flakyIdentity(2)
+	sum, err := SumEvenTransform(numbers, 1, transform, WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatalf("expected the retries to succeed, got %v", err)
+	}
+	if sum != 2550 {
+		t.Errorf("expected 2550, got %d", sum)
+	}
+	if calls.Load() != 102 {
+		t.Errorf("expected 100 calls plus 2 retries, got %d", calls.Load())
+	}
+
+	// Several workers still add up correctly
+	transform, _ = flakyIdentity(2)
+	if sum, err := SumEvenTransform(numbers, 4, transform, WithRetry(3, time.Millisecond)); err != nil || sum != 2550 {
+		t.Errorf("expected 2550, got %d (err %v)", sum, err)
+	}
+}
+
+// TestSumEvenTransform_Error tests that the error surfaces once retries are exhausted
+func TestSumEvenTransform_Error(t *testing.T) {
+	numbers := []int{1, 2, 3, 4}
+
+	transform, _ := flakyIdentity(2)
+	if _, err := SumEvenTransform(numbers, 1, transform); err == nil {
+		t.Error("expected an error without retries")
+	}
+
+	transform, calls := flakyIdentity(10)
+	if _, err := SumEvenTransform(numbers, 1, transform, WithRetry(3, time.Millisecond)); err == nil {
+		t.Error("expected an error once the attempts are used up")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls.Load())
+	}
+}
+
+// TestSumEvenTransformContext_EarlyReturn tests that an error from one worker returns right away,
+// cancels the slow workers and leaves no goroutine behind
+func TestSumEvenTransformContext_EarlyReturn(t *testing.T) {
+	numbers := make([]int, 100)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	errWorker2 := errors.New("worker 2 failed")
+
+	before := runtime.NumGoroutine()
+	start := time.Now()
+	// With 4 workers the second chunk is 26..50, it fails on its first number while the other
+	// workers would need 25 * 50ms each
+	_, err := SumEvenTransformContext(context.Background(), numbers, 4, func(ctx context.Context, n int) (int, error) {
+		if n > 25 && n <= 50 {
+			return 0, errWorker2
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return n, nil
+		}
+	})
+	if !errors.Is(err, errWorker2) {
+		t.Fatalf("expected worker 2's error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("expected a prompt return, took %v", elapsed)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("leaked %d goroutines", after-before)
+	}
+}
+
+// TestSumEvenTransformContext_Cancelled tests that a cancelled parent context stops the sum
+func TestSumEvenTransformContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := SumEvenTransformContext(ctx, []int{1, 2, 3, 4}, 2, func(ctx context.Context, n int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
@@ -2,27 +2,41 @@ package main
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
 )
 
-// calculateEvenSum processes a slice chunk and sends the sum of even numbers to the results channel
-func calculateEvenSum(numbers []int, results chan<- int, wg *sync.WaitGroup) {
+// calculateEvenSum processes a slice chunk and sends the sum of even numbers to the results channel.
+// With batchSize > 0 a partial sum is sent after every batchSize numbers instead of once per chunk
+func calculateEvenSum(numbers []int, batchSize int, results chan<- int, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	sum := 0
-	for _, num := range numbers {
-		if num%2 == 0 {
-			sum += num
+	if batchSize <= 0 {
+		batchSize = len(numbers)
+	}
+	for start := 0; start < len(numbers); start += batchSize {
+		sum := 0
+		for _, num := range numbers[start:min(start+batchSize, len(numbers))] {
+			if num%2 == 0 {
+				sum += num
+			}
 		}
+		results <- sum
 	}
-
-	results <- sum
 }
 
 // sumEvenNumbersConcurrent divides the slice among workers and calculates sum concurrently
 func sumEvenNumbersConcurrent(numbers []int, numWorkers int) int {
+	return sumEvenNumbersStreaming(numbers, numWorkers, 0)
+}
+
+// sumEvenNumbersStreaming is sumEvenNumbersConcurrent where each worker sends a partial sum
+// for every batchSize numbers, 0 means one partial per worker.
+//
+// Invariant: the caller drains results while the workers are still running and the channel
+// is only closed after wg.Wait, so workers may send any number of partials without deadlocking.
+// The buffer size only affects throughput, never correctness
+func sumEvenNumbersStreaming(numbers []int, numWorkers int, batchSize int) int {
 	if len(numbers) == 0 {
 		return 0
 	}
@@ -32,42 +46,15 @@ func sumEvenNumbersConcurrent(numbers []int, numWorkers int) int {
 	var wg sync.WaitGroup
 
 	// use chunk so each worker not process entire numbers
-	chunkSize := len(numbers) / numWorkers //10/4
-
 	//The remainder tells you how many workers should get one extra item so all items are processed.
 	// contoh jika numbers=10 dan numWorkers=3, maka chunkSize=3 dan remainder=1
 	//Worker 1: Mengerjakan 4 angka ([1, 2, 3, 4]) ( ditambah 1 dari remainder) startIdx = 0, endIdx = 0 + 4 = 4 , startIdx = 4
 	//Worker 2: Mengerjakan 3 angka ([5, 6, 7]) startIdx = 4, endIdx = 4 + 3 = 7 , startIdx = 7
 	//Worker 3: Mengerjakan 3 angka ([8, 9, 10]) startIdx = 7, endIdx = 7 + 3 = 10 , startIdx = 10
-
-	remainder := len(numbers) % numWorkers
-
-	startIdx := 0
-	for i := 0; i < numWorkers; i++ {
-		// Adjust chunk size to distribute remainder
-		currentChunkSize := chunkSize
-		if i < remainder {
-			currentChunkSize++
-		}
-
-		// Calculate end index
-		endIdx := startIdx + currentChunkSize
-		if endIdx > len(numbers) {
-			endIdx = len(numbers)
-		}
-
-		// Skip if no elements to process
-		if startIdx >= len(numbers) {
-			break
-		}
-
-		log.Println(numbers[startIdx:endIdx], remainder, currentChunkSize)
-
+	for _, c := range chunkRanges(len(numbers), numWorkers) {
 		// Launch goroutine for this chunk
 		wg.Add(1)
-		go calculateEvenSum(numbers[startIdx:endIdx], results, &wg)
-
-		startIdx = endIdx
+		go calculateEvenSum(numbers[c.start:c.end], batchSize, results, &wg)
 	}
 
 	// Close results channel when all workers are done
@@ -78,10 +65,9 @@ func sumEvenNumbersConcurrent(numbers []int, numWorkers int) int {
 		close(results)
 	}()
 
-	// Collect results from all workers
+	// Collect results from all workers, this must not wait for wg first (see the invariant above)
 	totalSum := 0
 	for partialSum := range results {
-		log.Println("partialsum", partialSum)
 		totalSum += partialSum
 	}
 
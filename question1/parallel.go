@@ -0,0 +1,74 @@
+package main
+
+import "sync"
+
+// chunk is a half-open [start, end) range of a slice handled by one worker
+type chunk struct {
+	start, end int
+}
+
+// chunkRanges splits n items into at most numWorkers contiguous chunks, the first n%numWorkers
+// chunks get one extra item so every item is covered. Empty chunks are never returned
+func chunkRanges(n, numWorkers int) []chunk {
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	chunkSize := n / numWorkers
+	remainder := n % numWorkers
+
+	chunks := make([]chunk, 0, numWorkers)
+	startIdx := 0
+	for i := 0; i < numWorkers && startIdx < n; i++ {
+		currentChunkSize := chunkSize
+		if i < remainder {
+			currentChunkSize++
+		}
+		chunks = append(chunks, chunk{start: startIdx, end: startIdx + currentChunkSize})
+		startIdx += currentChunkSize
+	}
+	return chunks
+}
+
+// ParallelMap applies fn to every element using the given number of workers and returns the
+// results in input order. Each worker writes only its own region of the output, so no mutex is needed
+func ParallelMap[T, R any](in []T, workers int, fn func(T) R) []R {
+	out := make([]R, len(in))
+	var wg sync.WaitGroup
+	for _, c := range chunkRanges(len(in), workers) {
+		wg.Add(1)
+		go func(c chunk) {
+			defer wg.Done()
+			for i := c.start; i < c.end; i++ {
+				out[i] = fn(in[i])
+			}
+		}(c)
+	}
+	wg.Wait()
+	return out
+}
+
+// ParallelFilter returns the elements for which keep returns true, in input order.
+// Each worker filters its chunk into its own slice, the slices are joined in chunk order
+func ParallelFilter[T any](in []T, workers int, keep func(T) bool) []T {
+	chunks := chunkRanges(len(in), workers)
+	kept := make([][]T, len(chunks))
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c chunk) {
+			defer wg.Done()
+			for _, v := range in[c.start:c.end] {
+				if keep(v) {
+					kept[i] = append(kept[i], v)
+				}
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	out := make([]T, 0, len(in))
+	for _, part := range kept {
+		out = append(out, part...)
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestChunkRanges tests that chunks cover every item and spread the remainder
+func TestChunkRanges(t *testing.T) {
+	got := chunkRanges(10, 3)
+	want := []chunk{{0, 4}, {4, 7}, {7, 10}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// More workers than items never produces empty chunks
+	if got := chunkRanges(2, 5); len(got) != 2 {
+		t.Errorf("expected 2 chunks, got %v", got)
+	}
+	if got := chunkRanges(0, 4); len(got) != 0 {
+		t.Errorf("expected no chunks, got %v", got)
+	}
+}
+
+// TestParallelMap tests that results keep the input order for any worker count
+func TestParallelMap(t *testing.T) {
+	in := make([]int, 101)
+	want := make([]string, len(in))
+	for i := range in {
+		in[i] = i
+		want[i] = strconv.Itoa(i * i)
+	}
+
+	for _, workers := range []int{0, 1, 3, 8, 200} {
+		got := ParallelMap(in, workers, func(n int) string { return strconv.Itoa(n * n) })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d: output order not preserved", workers)
+		}
+	}
+
+	if got := ParallelMap([]int{}, 4, func(n int) int { return n }); len(got) != 0 {
+		t.Errorf("expected empty output, got %v", got)
+	}
+}
+
+// TestParallelFilter tests that kept elements keep the input order
+func TestParallelFilter(t *testing.T) {
+	in := []int{9, 2, 7, 4, 4, 1, 6, 8, 3, 10}
+	isEven := func(n int) bool { return n%2 == 0 }
+
+	for _, workers := range []int{1, 3, 4, 20} {
+		got := ParallelFilter(in, workers, isEven)
+		want := []int{2, 4, 4, 6, 8, 10}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("workers=%d: expected %v, got %v", workers, want, got)
+		}
+	}
+}
+
+// TestSumEvenNumbersConcurrent tests the sum against the sequential result
+func TestSumEvenNumbersConcurrent(t *testing.T) {
+	numbers := make([]int, 100)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	if sum := sumEvenNumbersConcurrent(numbers, 4); sum != 2550 {
+		t.Errorf("expected 2550, got %d", sum)
+	}
+}
+
+// TestSumEvenNumbersStreaming tests that workers emitting many more partials than the
+// channel buffer holds neither deadlock nor lose partial sums
+func TestSumEvenNumbersStreaming(t *testing.T) {
+	numbers := make([]int, 10000)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+
+	for _, batchSize := range []int{1, 7, 0} {
+		done := make(chan int)
+		go func() { done <- sumEvenNumbersStreaming(numbers, 4, batchSize) }()
+
+		select {
+		case sum := <-done:
+			if sum != 25005000 {
+				t.Errorf("batchSize=%d: expected 25005000, got %d", batchSize, sum)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("batchSize=%d: deadlocked", batchSize)
+		}
+	}
+}
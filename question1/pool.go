@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Submit after Close
+var ErrPoolClosed = errors.New("pool is closed")
+
+// Pool runs fn on submitted jobs with a fixed number of workers. Jobs wait in a bounded queue,
+// so producers that outpace the workers are slowed down instead of growing memory
+type Pool[In, Out any] struct {
+	jobs    chan In
+	results chan Out
+	fn      func(In) Out
+	wg      sync.WaitGroup
+	mu      sync.RWMutex // held for reading while submitting, so Close can't close jobs mid-send
+	closed  bool
+}
+
+// NewPool starts workers goroutines reading from a queue holding at most queueSize pending jobs.
+// Results must be consumed from Results, otherwise workers block and so does Submit
+func NewPool[In, Out any](workers, queueSize int, fn func(In) Out) *Pool[In, Out] {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool[In, Out]{
+		jobs:    make(chan In, queueSize),
+		results: make(chan Out, queueSize),
+		fn:      fn,
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				p.results <- p.fn(job)
+			}
+		}()
+	}
+	return p
+}
+
+// Submit queues a job, blocking while the queue is full. It returns ErrPoolClosed after Close
+func (p *Pool[In, Out]) Submit(job In) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrPoolClosed
+	}
+	p.jobs <- job
+	return nil
+}
+
+// TrySubmit queues a job without blocking, returning false when the queue is full or the pool is closed
+func (p *Pool[In, Out]) TrySubmit(job In) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return false
+	}
+	select {
+	case p.jobs <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// Results returns the channel receiving every job's output, in completion order.
+// It is closed once the pool is closed and all jobs have finished
+func (p *Pool[In, Out]) Results() <-chan Out {
+	return p.results
+}
+
+// Close stops accepting jobs. Jobs already queued or running are not dropped: the workers drain
+// the queue and Results is closed after the last one finishes. Close waits for blocked Submit
+// calls to hand off their jobs but not for the jobs to run. Calling it again is a no-op
+func (p *Pool[In, Out]) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.jobs)
+
+	go func() {
+		p.wg.Wait()
+		close(p.results)
+	}()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+)
+
+// TestPool_Backpressure tests a full queue with blocking Submit and TrySubmit
+func TestPool_Backpressure(t *testing.T) {
+	gate := make(chan struct{})
+	pool := NewPool(1, 1, func(n int) int {
+		<-gate
+		return n * 10
+	})
+
+	// The worker takes job 1 and blocks, job 2 fills the queue
+	if err := pool.Submit(1); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !pool.TrySubmit(2) {
+		if time.Now().After(deadline) {
+			t.Fatal("the worker never picked up job 1")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if pool.TrySubmit(3) {
+		t.Fatal("TrySubmit should fail while the queue is full")
+	}
+
+	submitted := make(chan error)
+	go func() { submitted <- pool.Submit(3) }()
+	select {
+	case <-submitted:
+		t.Fatal("Submit should block while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(gate)
+	if err := <-submitted; err != nil {
+		t.Fatalf("blocked Submit failed: %v", err)
+	}
+
+	pool.Close()
+	var results []int
+	for result := range pool.Results() {
+		results = append(results, result)
+	}
+	sort.Ints(results)
+	if len(results) != 3 || results[0] != 10 || results[2] != 30 {
+		t.Errorf("expected every queued job to be drained, got %v", results)
+	}
+}
+
+// TestPool_Closed tests that a closed pool rejects new jobs
+func TestPool_Closed(t *testing.T) {
+	pool := NewPool(2, 4, func(n int) int { return n })
+	pool.Close()
+	pool.Close()
+
+	if err := pool.Submit(1); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("expected ErrPoolClosed, got %v", err)
+	}
+	if pool.TrySubmit(1) {
+		t.Error("TrySubmit should fail on a closed pool")
+	}
+	if _, ok := <-pool.Results(); ok {
+		t.Error("Results should be closed")
+	}
+}
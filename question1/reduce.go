@@ -0,0 +1,62 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// indexedPartial is a worker's result tagged with the index of the chunk it processed
+type indexedPartial[R any] struct {
+	index int
+	value R
+}
+
+// OrderedReduce splits in into chunks processed concurrently by reduceChunk, then folds the partial
+// results with combine in chunk order starting from initial. Unlike the even sum, which adds
+// partials in whatever order they arrive, this keeps non-commutative reductions such as string
+// concatenation equal to the sequential result
+func OrderedReduce[T, R any](in []T, numWorkers int, reduceChunk func([]T) R, combine func(acc, partial R) R, initial R) R {
+	chunks := chunkRanges(len(in), numWorkers)
+	results := make(chan indexedPartial[R], len(chunks))
+	var wg sync.WaitGroup
+
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c chunk) {
+			defer wg.Done()
+			results <- indexedPartial[R]{index: i, value: reduceChunk(in[c.start:c.end])}
+		}(i, c)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Partials arrive in completion order, sort them back into chunk order before folding
+	partials := make([]indexedPartial[R], 0, len(chunks))
+	for partial := range results {
+		partials = append(partials, partial)
+	}
+	sort.Slice(partials, func(i, j int) bool { return partials[i].index < partials[j].index })
+
+	acc := initial
+	for _, partial := range partials {
+		acc = combine(acc, partial.value)
+	}
+	return acc
+}
+
+// Reduce folds items with combine in parallel: each chunk is reduced starting from identity,
+// then the partials are reduced the same way in chunk order. combine must be associative and
+// identity must be its neutral element, an empty slice returns identity
+func Reduce[T any](items []T, workers int, identity T, combine func(a, b T) T) T {
+	reduceChunk := func(chunk []T) T {
+		acc := identity
+		for _, item := range chunk {
+			acc = combine(acc, item)
+		}
+		return acc
+	}
+	return OrderedReduce(items, workers, reduceChunk, combine, identity)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOrderedReduce tests that concatenating per-chunk strings matches the sequential order
+func TestOrderedReduce(t *testing.T) {
+	in := make([]int, 50)
+	var sequential strings.Builder
+	for i := range in {
+		in[i] = i
+		sequential.WriteString(strconv.Itoa(i) + ",")
+	}
+
+	// Earlier chunks sleep longer so they finish last and arrive out of order
+	concatChunk := func(chunk []int) string {
+		time.Sleep(time.Duration(50-chunk[0]) * time.Millisecond / 10)
+		var b strings.Builder
+		for _, n := range chunk {
+			b.WriteString(strconv.Itoa(n) + ",")
+		}
+		return b.String()
+	}
+	concat := func(acc, partial string) string { return acc + partial }
+
+	for _, workers := range []int{1, 3, 7, 50} {
+		if got := OrderedReduce(in, workers, concatChunk, concat, ""); got != sequential.String() {
+			t.Errorf("workers=%d: expected %q, got %q", workers, sequential.String(), got)
+		}
+	}
+
+	if got := OrderedReduce([]int{}, 4, concatChunk, concat, "empty"); got != "empty" {
+		t.Errorf("expected the initial value for empty input, got %q", got)
+	}
+}
+
+// TestReduce tests sum, product and max for several worker counts, and identity for empty input
+func TestReduce(t *testing.T) {
+	in := make([]int, 20)
+	for i := range in {
+		in[i] = i + 1
+	}
+	product := 1
+	for _, n := range in[:10] {
+		product *= n
+	}
+
+	tests := []struct {
+		name     string
+		items    []int
+		identity int
+		combine  func(a, b int) int
+		want     int
+	}{
+		{"sum", in, 0, func(a, b int) int { return a + b }, 210},
+		{"product", in[:10], 1, func(a, b int) int { return a * b }, product},
+		{"max", []int{3, -7, 42, 8, 41, 0}, math.MinInt, func(a, b int) int { return max(a, b) }, 42},
+		{"empty", []int{}, 7, func(a, b int) int { return a + b }, 7},
+	}
+
+	for _, tt := range tests {
+		for _, workers := range []int{1, 3, 8, 50} {
+			if got := Reduce(tt.items, workers, tt.identity, tt.combine); got != tt.want {
+				t.Errorf("%s with %d workers: expected %d, got %d", tt.name, workers, tt.want, got)
+			}
+		}
+	}
+}
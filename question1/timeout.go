@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// timeoutCheckInterval is how many numbers a worker sums between checks of the deadline
+const timeoutCheckInterval = 4096
+
+// SumEvenWithTimeout is sumEvenNumbersConcurrent with a deadline: if not every worker has sent
+// its partial sum within timeout, it returns an error wrapping context.DeadlineExceeded.
+// The results channel has room for one send per worker, so workers still running when the
+// collector gives up finish their send without blocking and exit, nothing is leaked
+func SumEvenWithTimeout(numbers []int, workers int, timeout time.Duration) (int, error) {
+	return sumEvenWithTimeout(numbers, workers, timeout, sumEvenUntilDone)
+}
+
+// sumEvenWithTimeout runs sumChunk for every chunk and collects the partials until the deadline.
+// sumChunk may stop early once ctx is done, its partial is then discarded
+func sumEvenWithTimeout(numbers []int, workers int, timeout time.Duration, sumChunk func(ctx context.Context, numbers []int) int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel() // also tells abandoned workers to stop
+
+	chunks := chunkRanges(len(numbers), workers)
+	results := make(chan int, len(chunks))
+	for _, c := range chunks {
+		go func(c chunk) {
+			results <- sumChunk(ctx, numbers[c.start:c.end])
+		}(c)
+	}
+
+	total := 0
+	for received := 0; received < len(chunks); received++ {
+		select {
+		case partial := <-results:
+			total += partial
+		case <-ctx.Done():
+			return 0, fmt.Errorf("sum even numbers: %d of %d partial sums after %v: %w", received, len(chunks), timeout, ctx.Err())
+		}
+	}
+	return total, nil
+}
+
+// sumEvenUntilDone sums the even numbers, returning early once ctx is done
+func sumEvenUntilDone(ctx context.Context, numbers []int) int {
+	sum := 0
+	for start := 0; start < len(numbers); start += timeoutCheckInterval {
+		if ctx.Err() != nil {
+			return sum
+		}
+		sum += sumEvenSequential(numbers[start:min(start+timeoutCheckInterval, len(numbers))])
+	}
+	return sum
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestSumEvenWithTimeout tests that a sum finishing in time matches the concurrent sum
+func TestSumEvenWithTimeout(t *testing.T) {
+	numbers := make([]int, 100_000)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+
+	for _, workers := range []int{1, 4, 16} {
+		sum, err := SumEvenWithTimeout(numbers, workers, time.Second)
+		if err != nil {
+			t.Fatalf("%d workers: unexpected error %v", workers, err)
+		}
+		if want := sumEvenSequential(numbers); sum != want {
+			t.Errorf("%d workers: expected %d, got %d", workers, want, sum)
+		}
+	}
+}
+
+// TestSumEvenWithTimeout_SlowWorker tests that a worker missing the deadline makes the sum fail
+// with context.DeadlineExceeded right away, and that the abandoned worker exits once it finishes
+func TestSumEvenWithTimeout_SlowWorker(t *testing.T) {
+	numbers := []int{2, 4, 6, 8}
+	release := make(chan struct{})
+	slowChunk := func(ctx context.Context, chunk []int) int {
+		if chunk[0] == 6 {
+			<-release // ignores ctx, like a transform stuck in I/O
+		}
+		return sumEvenSequential(chunk)
+	}
+
+	before := runtime.NumGoroutine()
+	start := time.Now()
+	_, err := sumEvenWithTimeout(numbers, 2, 50*time.Millisecond, slowChunk)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected a deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected to return at the deadline, took %v", elapsed)
+	}
+
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("leaked %d goroutines", after-before)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// Reset removes every user, soft-deleted ones included, and restarts the ID generator when it
+// can be rewound, so the next user gets ID 1 again. Subscribers get a delete for every user
+func (s *UserStore) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	users := sortedUsers(s.users)
+	for _, user := range users {
+		s.publish(ChangeDeleted, user)
+	}
+
+	s.users = make(map[UserID]*User)
+	if rewinder, ok := s.ids.(idRewinder); ok {
+		rewinder.rewind(0)
+	}
+	log.Printf("Reset store, removed %d users", len(users))
+	return nil
+}
+
+// ResetStore handles POST /admin/reset, removing every user and restarting IDs at 1.
+// Stored Idempotency-Key responses are dropped too, they would replay users that no longer exist.
+// Only registered when ServerConfig.AllowAdminReset is set
+func (h *UserHandler) ResetStore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	if err := h.store.Reset(); err != nil {
+		if errors.Is(err, ErrStoreClosed) {
+			respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+			return
+		}
+		log.Printf("reset store: %v", err)
+		respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to reset the store")
+		return
+	}
+	if h.idempotency != nil {
+		h.idempotency.Clear()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SeedUsers handles POST /admin/seed with a JSON array of users to create. Every user goes
+// through the bulk create validation, one at a time so IDs follow the order of the array.
+// Only registered when ServerConfig.AllowAdminReset is set
+func (h *UserHandler) SeedUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	var users []CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&users); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+	if len(users) > maxBulkCreateUsers {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", fmt.Sprintf("at most %d users are allowed", maxBulkCreateUsers))
+		return
+	}
+
+	respondWith(w, r, http.StatusOK, BulkCreateResponse{Results: h.bulkCreate(users, 1)})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestAdminReset tests that a reset clears users and stored idempotent responses and that the
+// next create starts again at ID 1
+func TestAdminReset(t *testing.T) {
+	server := newTestServer(t, ServerConfig{IdempotencyCache: newTestCache(t), AllowAdminReset: true})
+
+	doRequest(server, http.MethodPost, "/users", `{"name":"John Doe","email":"john@example.com"}`, "Idempotency-Key", "k1")
+	doRequest(server, http.MethodPost, "/users", `{"name":"Jane Doe","email":"jane@example.com"}`)
+
+	if rec := doRequest(server, http.MethodGet, "/admin/reset", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+	if rec := doRequest(server, http.MethodPost, "/admin/reset", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if rec := doRequest(server, http.MethodGet, "/users/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected user 1 to be gone, got %d", rec.Code)
+	}
+
+	// the same key must create a new user instead of replaying the one that was reset away
+	rec := doRequest(server, http.MethodPost, "/users", `{"name":"John Doe","email":"john@example.com"}`, "Idempotency-Key", "k1")
+	if rec.Code != http.StatusCreated || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("expected a fresh 201, got %d (replayed %q)", rec.Code, rec.Header().Get("Idempotent-Replayed"))
+	}
+	var user User
+	if err := json.NewDecoder(rec.Body).Decode(&user); err != nil || user.ID != "1" {
+		t.Errorf("expected the first user after reset to get ID 1, got %+v (err %v)", user, err)
+	}
+}
+
+// TestAdminSeed tests that seeded users get IDs in array order and invalid ones are reported per item
+func TestAdminSeed(t *testing.T) {
+	server := newTestServer(t, ServerConfig{AllowAdminReset: true})
+
+	body := `[
+		{"name":"John Doe","email":"john@example.com"},
+		{"name":"J","email":"j@example.com"},
+		{"name":"Jane Doe","email":"jane@example.com"}
+	]`
+	rec := doRequest(server, http.MethodPost, "/admin/seed", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp BulkCreateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != 3 || resp.Results[0].User.ID != "1" || resp.Results[1].Error == "" || resp.Results[2].User.ID != "2" {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+
+	if rec := doRequest(server, http.MethodPost, "/admin/seed", `{"name":"John"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a body that isn't an array, got %d", rec.Code)
+	}
+}
+
+// TestAdminDisabledByDefault tests that the admin endpoints don't exist without AllowAdminReset
+func TestAdminDisabledByDefault(t *testing.T) {
+	server := newTestServer(t, ServerConfig{})
+	doRequest(server, http.MethodPost, "/users", `{"name":"John Doe","email":"john@example.com"}`)
+
+	for _, path := range []string{"/admin/reset", "/admin/seed"} {
+		if rec := doRequest(server, http.MethodPost, path, `[]`); rec.Code != http.StatusNotFound {
+			t.Errorf("%s: expected 404, got %d", path, rec.Code)
+		}
+	}
+	if rec := doRequest(server, http.MethodGet, "/users/1", ""); rec.Code != http.StatusOK {
+		t.Errorf("users must survive a rejected reset, got %d", rec.Code)
+	}
+}
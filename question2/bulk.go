@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// maxBulkCreateUsers caps how many users a single bulk create may contain
+	maxBulkCreateUsers = 1000
+	// defaultBulkConcurrency is how many bulk items are processed at once when not configured
+	defaultBulkConcurrency = 8
+)
+
+// BulkCreateRequest represents the request body of POST /users/bulk
+type BulkCreateRequest struct {
+	Users []CreateUserRequest `json:"users"`
+}
+
+// BulkCreateResult is the outcome of one bulk item, either the created user or the error message
+type BulkCreateResult struct {
+	User  *User  `json:"user,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// BulkCreateResponse holds one result per requested user, in request order
+type BulkCreateResponse struct {
+	Results []BulkCreateResult `json:"results"`
+}
+
+// BulkCreateUsers handles POST /users/bulk. Every item is validated and created independently,
+// so one bad row doesn't fail the batch. At most bulkConcurrency items are in progress at once
+// regardless of the batch size, and results keep the order of the request
+func (h *UserHandler) BulkCreateUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	var req BulkCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+	if len(req.Users) == 0 {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", "users is required")
+		return
+	}
+	if len(req.Users) > maxBulkCreateUsers {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", fmt.Sprintf("at most %d users are allowed", maxBulkCreateUsers))
+		return
+	}
+
+	limit := h.bulkConcurrency
+	if limit <= 0 {
+		limit = defaultBulkConcurrency
+	}
+	respondWith(w, r, http.StatusOK, BulkCreateResponse{Results: h.bulkCreate(req.Users, limit)})
+}
+
+// bulkCreate creates every item with at most limit in progress at once, returning the results
+// in the order of items. With a limit of 1 the users get their IDs in that order too
+func (h *UserHandler) bulkCreate(items []CreateUserRequest, limit int) []BulkCreateResult {
+	results := make([]BulkCreateResult, len(items))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, item := range items {
+		// acquire before starting the goroutine, so a huge batch never has more than limit goroutines
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, item CreateUserRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = h.bulkCreateOne(item)
+		}(i, item)
+	}
+	wg.Wait()
+	return results
+}
+
+// bulkCreateOne validates and creates a single bulk item with the same rules as CreateUser
+func (h *UserHandler) bulkCreateOne(req CreateUserRequest) BulkCreateResult {
+	if err := h.validation.validateName(req.Name); err != nil {
+		return BulkCreateResult{Error: err.Error()}
+	}
+	if err := h.validation.validateEmail(req.Email); err != nil {
+		return BulkCreateResult{Error: err.Error()}
+	}
+	if err := validatePhone(req.Phone); err != nil {
+		return BulkCreateResult{Error: err.Error()}
+	}
+
+	user, err := h.store.Create(h.validation.storedName(req.Name), h.validation.storedEmail(req.Email), strings.TrimSpace(req.Phone))
+	switch {
+	case errors.Is(err, ErrEmailExists), errors.Is(err, ErrStoreFull), errors.Is(err, ErrStoreClosed):
+		return BulkCreateResult{Error: err.Error()}
+	case err != nil:
+		return BulkCreateResult{Error: "failed to create user"}
+	}
+	return BulkCreateResult{User: user}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// peakStore wraps a Store and records the highest number of concurrent Create calls
+type peakStore struct {
+	Store
+	inFlight, peak atomic.Int64
+}
+
+func (s *peakStore) Create(name, email, phone string) (*User, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if n <= peak || s.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond) // widen the window so concurrent calls overlap
+	return s.Store.Create(name, email, phone)
+}
+
+// TestBulkCreateUsers tests a large batch keeps per-item order and concurrency stays capped
+func TestBulkCreateUsers(t *testing.T) {
+	log.SetOutput(io.Discard) // Create logs every new ID
+	defer log.SetOutput(os.Stderr)
+
+	store := &peakStore{Store: NewUserStore()}
+	h := NewUserHandler(store)
+	h.bulkConcurrency = 4
+
+	const size = 300
+	items := make([]string, size)
+	for i := range items {
+		email := fmt.Sprintf("user%d@example.com", i)
+		switch {
+		case i%7 == 3:
+			email = "invalid"
+		case i == size-1:
+			email = "user0@example.com" // duplicate of the first item
+		}
+		items[i] = fmt.Sprintf(`{"name":"User %d","email":%q}`, i, email)
+	}
+	rec := doRequest(h, http.MethodPost, "/users/bulk", `{"users":[`+strings.Join(items, ",")+`]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp BulkCreateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Results) != size {
+		t.Fatalf("expected %d results, got %d", size, len(resp.Results))
+	}
+	created := 0
+	for i, result := range resp.Results {
+		switch {
+		case i%7 == 3:
+			if result.Error != "invalid email format" {
+				t.Errorf("item %d: expected invalid email format, got %+v", i, result)
+			}
+		case i == size-1:
+			if result.Error != ErrEmailExists.Error() {
+				t.Errorf("item %d: expected a duplicate email error, got %+v", i, result)
+			}
+		default:
+			if result.User == nil || result.User.Email != fmt.Sprintf("user%d@example.com", i) {
+				t.Errorf("item %d: result out of order or missing: %+v", i, result)
+			}
+			created++
+		}
+	}
+
+	if store.Count() != created {
+		t.Errorf("expected %d users stored, got %d", created, store.Count())
+	}
+	if peak := store.peak.Load(); peak > 4 {
+		t.Errorf("expected at most 4 concurrent creates, got %d", peak)
+	}
+}
+
+// TestBulkCreateUsers_Limits tests the empty and oversized batch checks
+func TestBulkCreateUsers_Limits(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+
+	if rec := doRequest(h, http.MethodPost, "/users/bulk", `{"users":[]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("empty batch: expected 400, got %d", rec.Code)
+	}
+
+	items := strings.Repeat(`{"name":"User","email":"u@example.com"},`, maxBulkCreateUsers+1)
+	rec := doRequest(h, http.MethodPost, "/users/bulk", `{"users":[`+strings.TrimSuffix(items, ",")+`]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("oversized batch: expected 400, got %d", rec.Code)
+	}
+	if rec := doRequest(h, http.MethodGet, "/users/bulk", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: expected 405, got %d", rec.Code)
+	}
+}
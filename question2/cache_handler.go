@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"question3/cache"
+)
+
+// CacheHandler exposes a shared TTLCache over HTTP for ad-hoc key/value storage
+type CacheHandler struct {
+	cache   *cache.TTLCache
+	metrics *Metrics
+}
+
+// NewCacheHandler creates a new CacheHandler, metrics may be nil
+func NewCacheHandler(c *cache.TTLCache, metrics *Metrics) *CacheHandler {
+	return &CacheHandler{cache: c, metrics: metrics}
+}
+
+// CacheValueRequest represents the request body for PUT /cache/:key
+type CacheValueRequest struct {
+	Value interface{} `json:"value"`
+}
+
+// CacheValueResponse represents a cached key and its value
+type CacheValueResponse struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// Router handles GET, PUT, DELETE /cache/:key
+func (h *CacheHandler) Router(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, "/cache/")
+	if key == "" || strings.Contains(key, "/") {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid cache key")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.GetValue(w, r, key)
+	case http.MethodPut:
+		h.PutValue(w, r, key)
+	case http.MethodDelete:
+		h.DeleteValue(w, r, key)
+	default:
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+	}
+}
+
+// GetValue handles GET /cache/:key
+func (h *CacheHandler) GetValue(w http.ResponseWriter, r *http.Request, key string) {
+	value, exists := h.cache.Get(key)
+	if !exists {
+		h.metrics.RecordCacheMiss()
+		respondWithError(w, r, http.StatusNotFound, "not_found", "Key not found")
+		return
+	}
+
+	h.metrics.RecordCacheHit()
+	respondWithJSON(w, r, http.StatusOK, CacheValueResponse{Key: key, Value: value})
+}
+
+// PutValue handles PUT /cache/:key with an optional ?ttl=30s, the cache default TTL is used otherwise
+func (h *CacheHandler) PutValue(w http.ResponseWriter, r *http.Request, key string) {
+	var ttl time.Duration
+	if raw := r.URL.Query().Get("ttl"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid ttl, expected a positive duration like 30s")
+			return
+		}
+		ttl = parsed
+	}
+
+	var req CacheValueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+
+	if ttl > 0 {
+		h.cache.SetWithTTL(key, req.Value, ttl)
+	} else {
+		h.cache.SetWithDefaultTTL(key, req.Value)
+	}
+
+	respondWithJSON(w, r, http.StatusOK, CacheValueResponse{Key: key, Value: req.Value})
+}
+
+// DeleteValue handles DELETE /cache/:key
+func (h *CacheHandler) DeleteValue(w http.ResponseWriter, r *http.Request, key string) {
+	h.cache.Delete(key)
+	respondWithJSON(w, r, http.StatusOK, map[string]string{"message": "Key deleted successfully"})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultCompressionMinSize is the smallest response body GzipMiddleware compresses when not
+// configured, below about one packet the gzip header and CPU cost outweigh the saving
+const defaultCompressionMinSize = 1024
+
+// GzipMiddleware compresses response bodies of at least minSize bytes for clients that send
+// Accept-Encoding: gzip, 0 uses defaultCompressionMinSize. Responses the handler already
+// encoded (Content-Encoding set) are passed through unchanged so nothing is compressed twice
+func GzipMiddleware(minSize int) func(http.Handler) http.Handler {
+	if minSize <= 0 {
+		minSize = defaultCompressionMinSize
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize, status: http.StatusOK}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, "gzip;q=0" refuses it
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding = strings.TrimSpace(coding); coding != "gzip" && coding != "*" {
+			continue
+		}
+		name, value, _ := strings.Cut(strings.TrimSpace(params), "=")
+		if strings.TrimSpace(name) == "q" {
+			if q, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && q == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of the body until it reaches minSize, then sends the
+// headers and switches to gzip. Bodies that end below minSize are sent as they are by close
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize     int
+	status      int
+	buf         []byte
+	started     bool // headers were sent, writes go to gz or straight through
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+// WriteHeader records the status, it is sent once the encoding is decided
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = status
+}
+
+// Write buffers p until the body is large enough to compress
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	if !w.started {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) < w.minSize {
+			return len(p), nil
+		}
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.gz != nil {
+		return w.gz.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush sends what was buffered so far, compressed if it already reached minSize
+func (w *gzipResponseWriter) Flush() {
+	if !w.started {
+		w.start(len(w.buf) >= w.minSize)
+	}
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// start sends the headers and the buffered body, through gzip when compress is set and the
+// handler hasn't chosen an encoding itself
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.started = true
+	header := w.Header()
+	if compress && header.Get("Content-Encoding") == "" && bodyAllowed(w.status) {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length") // it was the length before compression
+		w.ResponseWriter.WriteHeader(w.status)
+		w.gz = gzip.NewWriter(w.ResponseWriter)
+		_, err := w.gz.Write(w.buf)
+		w.buf = nil
+		return err
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// close sends a body that stayed below minSize uncompressed, or finishes the gzip stream
+func (w *gzipResponseWriter) close() {
+	if !w.started {
+		if !w.wroteHeader {
+			return // the handler wrote nothing, let net/http send its default 200
+		}
+		w.start(false)
+		return
+	}
+	if w.gz != nil {
+		w.gz.Close()
+	}
+}
+
+// bodyAllowed reports whether a response with status may carry a body
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
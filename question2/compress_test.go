@@ -0,0 +1,97 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGzipMiddleware tests which responses are compressed and that compressed bodies decode intact
+func TestGzipMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"John Doe","email":"john@example.com"},`, 100)
+	handler := func(body, encoding string, status int) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if encoding != "" {
+				w.Header().Set("Content-Encoding", encoding)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			// several writes, the first ones stay below the threshold
+			for _, part := range strings.SplitAfter(body, ",") {
+				io.WriteString(w, part)
+			}
+		})
+	}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		encoding       string // Content-Encoding set by the handler
+		status         int
+		wantGzip       bool
+	}{
+		{"large response", "gzip, deflate", large, "", http.StatusOK, true},
+		{"large error response", "gzip", large, "", http.StatusBadRequest, true},
+		{"tiny response", "gzip", `{"id":1}`, "", http.StatusOK, false},
+		{"client without gzip", "deflate", large, "", http.StatusOK, false},
+		{"gzip refused with q=0", "gzip;q=0, deflate", large, "", http.StatusOK, false},
+		{"already encoded", "gzip", large, "br", http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			GzipMiddleware(1024)(handler(tt.body, tt.encoding, tt.status)).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rec.Code)
+			}
+			if vary := rec.Header().Get("Vary"); vary != "Accept-Encoding" {
+				t.Errorf("expected Vary: Accept-Encoding, got %q", vary)
+			}
+
+			gzipped := rec.Header().Get("Content-Encoding") == "gzip"
+			if gzipped != tt.wantGzip {
+				t.Fatalf("expected gzip %v, got Content-Encoding %q", tt.wantGzip, rec.Header().Get("Content-Encoding"))
+			}
+			body := rec.Body.String()
+			if gzipped {
+				if rec.Body.Len() >= len(tt.body) {
+					t.Errorf("compressed body (%d bytes) should be smaller than %d bytes", rec.Body.Len(), len(tt.body))
+				}
+				zr, err := gzip.NewReader(rec.Body)
+				if err != nil {
+					t.Fatalf("invalid gzip stream: %v", err)
+				}
+				decoded, err := io.ReadAll(zr)
+				if err != nil {
+					t.Fatalf("truncated gzip stream: %v", err)
+				}
+				body = string(decoded)
+			}
+			if body != tt.body {
+				t.Errorf("body changed by the middleware, got %d bytes, want %d", len(body), len(tt.body))
+			}
+		})
+	}
+}
+
+// TestGzipMiddleware_NoBody tests that responses without a body keep their status and stay unencoded
+func TestGzipMiddleware_NoBody(t *testing.T) {
+	handler := GzipMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	req := httptest.NewRequest(http.MethodDelete, "/users/1", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 0 {
+		t.Errorf("expected a bare 204, got %d %q with %d bytes", rec.Code, rec.Header().Get("Content-Encoding"), rec.Body.Len())
+	}
+}
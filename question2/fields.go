@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// userFields are the JSON field names a ?fields projection may select, taken from the User json tags
+var userFields = schemaFor(reflect.TypeOf(User{})).Properties
+
+// parseFields reads a ?fields=id,name projection. A missing parameter returns nil, an unknown
+// or empty field name is an error
+func parseFields(r *http.Request) ([]string, error) {
+	raw, present := r.URL.Query()["fields"]
+	if !present {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(strings.Join(raw, ","), ",") {
+		field = strings.TrimSpace(field)
+		if _, ok := userFields[field]; !ok {
+			known := make([]string, 0, len(userFields))
+			for name := range userFields {
+				known = append(known, name)
+			}
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown field %q, use %s", field, strings.Join(known, ", "))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// projectUser returns only the selected fields of user, fields the user omits (e.g. an empty phone)
+// stay absent
+func projectUser(user *User, fields []string) map[string]interface{} {
+	body, _ := json.Marshal(user)
+	var all map[string]json.RawMessage
+	json.Unmarshal(body, &all)
+
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected
+}
+
+// projectUsers applies projectUser to every user, keeping their order
+func projectUsers(users []*User, fields []string) []map[string]interface{} {
+	projected := make([]map[string]interface{}, len(users))
+	for i, user := range users {
+		projected[i] = projectUser(user, fields)
+	}
+	return projected
+}
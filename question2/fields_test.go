@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestPrettyJSON tests that ?pretty=true indents the output and the default stays compact
+func TestPrettyJSON(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "")
+	h := NewUserHandler(store)
+
+	compact := doRequest(h, http.MethodGet, "/users/1", "")
+	if strings.Contains(compact.Body.String(), "\n  ") {
+		t.Errorf("expected compact output by default, got %s", compact.Body.String())
+	}
+
+	pretty := doRequest(h, http.MethodGet, "/users/1?pretty=true", "")
+	if pretty.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", pretty.Code)
+	}
+	if !strings.Contains(pretty.Body.String(), "{\n  \"id\": 1,\n  \"name\": \"John Doe\"") {
+		t.Errorf("expected indented output, got %s", pretty.Body.String())
+	}
+
+	var a, b User
+	json.Unmarshal(compact.Body.Bytes(), &a)
+	json.Unmarshal(pretty.Body.Bytes(), &b)
+	if a != b {
+		t.Errorf("pretty output decodes differently: %+v vs %+v", a, b)
+	}
+}
+
+// TestFieldsProjection tests selecting user fields on GET /users/:id and GET /users
+func TestFieldsProjection(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "+6281234567890")
+	store.Create("Jane Doe", "jane@example.com", "")
+	h := NewUserHandler(store)
+
+	rec := doRequest(h, http.MethodGet, "/users/1?fields=id,name", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var user map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if len(user) != 2 || user["id"] != float64(1) || user["name"] != "John Doe" {
+		t.Errorf("expected only id and name, got %v", user)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/users?fields=email,phone", "")
+	var users []map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&users); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if len(users) != 2 || len(users[0]) != 2 || users[0]["phone"] != "+6281234567890" {
+		t.Errorf("unexpected first user: %v", users)
+	}
+	if _, hasPhone := users[1]["phone"]; hasPhone || users[1]["email"] != "jane@example.com" {
+		t.Errorf("expected an omitted phone to stay absent, got %v", users[1])
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"unknown field", "/users/1?fields=id,password"},
+		{"empty field", "/users/1?fields=id,"},
+		{"unknown field in list", "/users?fields=nickname"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := doRequest(h, http.MethodGet, tt.path, ""); rec.Code != http.StatusBadRequest {
+				t.Errorf("expected 400, got %d", rec.Code)
+			}
+		})
+	}
+}
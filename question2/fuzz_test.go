@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// FuzzValidateEmail tests that validateEmail never panics and that every accepted address
+// has exactly one "@" with a non-empty local part and domain
+func FuzzValidateEmail(f *testing.F) {
+	for _, seed := range []string{
+		"john@example.com",
+		"a@b",
+		"a@b.c",
+		"@example.com",
+		"john@",
+		"john@@example.com",
+		"jo@hn@example.com",
+		"  john@example.com  ",
+		"jöhn@exämple.com",
+		"john@例え.jp",
+		"john\x00@example.com",
+		strings.Repeat("a", 10000) + "@example.com",
+		"john@" + strings.Repeat("a.", 5000) + "com",
+	} {
+		f.Add(seed)
+	}
+
+	var v ValidationConfig
+	f.Fuzz(func(t *testing.T, email string) {
+		if err := v.validateEmail(email); err != nil {
+			return
+		}
+		email = strings.TrimSpace(email)
+		if strings.Count(email, "@") != 1 {
+			t.Fatalf("accepted %q without exactly one @", email)
+		}
+		local, domain, _ := strings.Cut(email, "@")
+		if local == "" || domain == "" {
+			t.Fatalf("accepted %q with an empty local part or domain", email)
+		}
+	})
+}
+
+// FuzzValidateName tests that validateName never panics and that every accepted name
+// is within the default length bounds once normalized
+func FuzzValidateName(f *testing.F) {
+	for _, seed := range []string{
+		"John",
+		"J",
+		"  John   Doe  ",
+		"Jöhn Dœ",
+		"李小龍",
+		"John\u200bDoe",
+		"John\x00",
+		"\xff\xfe",
+		strings.Repeat("a", 100),
+		strings.Repeat("a", 101),
+		strings.Repeat("é", 10000),
+	} {
+		f.Add(seed)
+	}
+
+	var v ValidationConfig
+	f.Fuzz(func(t *testing.T, name string) {
+		if err := v.validateName(name); err != nil {
+			return
+		}
+		length := utf8.RuneCountInString(normalizeName(name))
+		if length < defaultMinNameLength || length > defaultMaxNameLength {
+			t.Fatalf("accepted %q with %d runes", name, length)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// fakeStore is a minimal Store for handler tests. Only the overridden methods work,
+// calling any other method panics on the nil embedded interface
+type fakeStore struct {
+	Store
+	users     map[UserID]*User
+	createErr error
+}
+
+func (f *fakeStore) Get(id UserID) (*User, bool) {
+	user, exists := f.users[id]
+	return user, exists
+}
+
+func (f *fakeStore) Create(name, email, phone string) (*User, error) {
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+	user := &User{ID: UserID(strconv.Itoa(len(f.users) + 1)), Name: name, Email: email, Phone: phone, Version: 1}
+	f.users[user.ID] = user
+	return user, nil
+}
+
+// TestUserHandler_WithFakeStore tests the handlers against a fake backend
+func TestUserHandler_WithFakeStore(t *testing.T) {
+	store := &fakeStore{users: map[UserID]*User{"7": {ID: "7", Name: "Fake", Email: "fake@example.com", Version: 1}}}
+	h := NewUserHandler(store)
+
+	rec := doRequest(h, http.MethodGet, "/users/7", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var user User
+	json.NewDecoder(rec.Body).Decode(&user)
+	if user.Name != "Fake" {
+		t.Errorf("expected the fake user, got %+v", user)
+	}
+
+	// A backend failure other than a duplicate email maps to 500
+	store.createErr = errors.New("disk full")
+	rec = doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 on backend failure, got %d", rec.Code)
+	}
+
+	store.createErr = ErrEmailExists
+	rec = doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 on duplicate email, got %d", rec.Code)
+	}
+}
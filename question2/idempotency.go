@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"question3/cache"
+)
+
+// idempotencyTTL is how long a created response is replayed for its Idempotency-Key,
+// use it as the default TTL of the cache passed as ServerConfig.IdempotencyCache
+const idempotencyTTL = 24 * time.Hour
+
+// maxIdempotentBodyBytes caps the request body buffered for an Idempotency-Key, it is read
+// whole before validation to fingerprint it. A create request is far smaller
+const maxIdempotentBodyBytes = 1 << 20
+
+// capturedResponse is a response recorded for replay under an Idempotency-Key
+type capturedResponse struct {
+	fingerprint [sha256.Size]byte // hash of the request body the response belongs to
+	status      int
+	header      http.Header
+	body        []byte
+}
+
+// Error lets a non-201 response travel through GetOrCompute without being cached
+func (c *capturedResponse) Error() string {
+	return http.StatusText(c.status)
+}
+
+// write replays the response to w
+func (c *capturedResponse) write(w http.ResponseWriter) {
+	for name, values := range c.header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(c.status)
+	w.Write(c.body)
+}
+
+// responseCapture is a ResponseWriter that buffers the response instead of sending it
+type responseCapture struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (c *responseCapture) Header() http.Header { return c.header }
+
+func (c *responseCapture) WriteHeader(status int) { c.status = status }
+
+func (c *responseCapture) Write(p []byte) (int, error) { return c.body.Write(p) }
+
+// idempotent runs handler once per Idempotency-Key, replaying its 201 response to repeats of the
+// same request while the key lives in store. Other responses are not stored so the client can
+// retry after fixing the request. Reusing the key of a stored response with a different body is
+// rejected with 422, a body over maxIdempotentBodyBytes with 413.
+// Concurrent requests with the same key share one handler call
+func idempotent(store *cache.TTLCache, key string, w http.ResponseWriter, r *http.Request, handler http.HandlerFunc) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			respondWithError(w, r, http.StatusRequestEntityTooLarge, "request_too_large",
+				fmt.Sprintf("Request body must not exceed %d bytes", maxIdempotentBodyBytes))
+			return
+		}
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Failed to read request body")
+		return
+	}
+	fingerprint := sha256.Sum256(body)
+
+	for {
+		replayed := true
+		value, err := store.GetOrCompute(key, func() (interface{}, error) {
+			replayed = false
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			capture := &responseCapture{header: make(http.Header), status: http.StatusOK}
+			handler(capture, r)
+
+			resp := &capturedResponse{
+				fingerprint: fingerprint,
+				status:      capture.status,
+				header:      capture.header,
+				body:        capture.body.Bytes(),
+			}
+			if resp.status != http.StatusCreated {
+				return nil, resp
+			}
+			return resp, nil
+		})
+
+		resp, stored := value.(*capturedResponse)
+		if !stored {
+			// the cache itself can fail (closed, breaker open), only captured responses are replayable
+			var ok bool
+			if resp, ok = err.(*capturedResponse); !ok {
+				respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to process request")
+				return
+			}
+		}
+		if resp.fingerprint != fingerprint {
+			if !stored {
+				// we joined a concurrent request with another body that failed, nothing is kept
+				// under the key, so run this request on its own
+				continue
+			}
+			respondWithError(w, r, http.StatusUnprocessableEntity, "idempotency_key_reused",
+				"Idempotency-Key was already used with a different request body")
+			return
+		}
+		if replayed && resp.status == http.StatusCreated {
+			w.Header().Set("Idempotent-Replayed", "true")
+		}
+		resp.write(w)
+		return
+	}
+}
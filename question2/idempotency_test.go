@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// userCount returns the count reported by GET /users/count
+func userCount(t *testing.T, handler http.Handler) int {
+	t.Helper()
+	var body map[string]int
+	if err := json.NewDecoder(doRequest(handler, http.MethodGet, "/users/count", "").Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	return body["count"]
+}
+
+// TestCreateUser_IdempotencyKey tests that a repeated request is answered from the stored response
+func TestCreateUser_IdempotencyKey(t *testing.T) {
+	server := newTestServer(t, ServerConfig{IdempotencyCache: newTestCache(t)})
+	body := `{"name":"John Doe","email":"john@example.com"}`
+
+	first := doRequest(server, http.MethodPost, "/users", body, "Idempotency-Key", "key-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("the first response must not be marked as replayed")
+	}
+
+	second := doRequest(server, http.MethodPost, "/users", body, "Idempotency-Key", "key-1")
+	if second.Code != http.StatusCreated {
+		t.Fatalf("expected replayed 201, got %d: %s", second.Code, second.Body.String())
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("expected the same body, got %s and %s", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get("Location") != "/users/1" || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("unexpected replay headers: %v", second.Header())
+	}
+	if count := userCount(t, server); count != 1 {
+		t.Errorf("expected 1 user, got %d", count)
+	}
+
+	// without the header the duplicate email is rejected as usual
+	if rec := doRequest(server, http.MethodPost, "/users", body); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a key, got %d", rec.Code)
+	}
+}
+
+// TestCreateUser_IdempotencyKeyConflict tests that reusing a key with another body is rejected
+func TestCreateUser_IdempotencyKeyConflict(t *testing.T) {
+	server := newTestServer(t, ServerConfig{IdempotencyCache: newTestCache(t)})
+
+	doRequest(server, http.MethodPost, "/users", `{"name":"John Doe","email":"john@example.com"}`, "Idempotency-Key", "key-1")
+	rec := doRequest(server, http.MethodPost, "/users", `{"name":"Jane Doe","email":"jane@example.com"}`, "Idempotency-Key", "key-1")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	if code := decodeError(t, rec).Error; code != "idempotency_key_reused" {
+		t.Errorf("unexpected error code: %s", code)
+	}
+	if count := userCount(t, server); count != 1 {
+		t.Errorf("expected 1 user, got %d", count)
+	}
+}
+
+// TestCreateUser_IdempotencyKeyFailure tests that failed requests are not stored, so a fixed retry succeeds
+func TestCreateUser_IdempotencyKeyFailure(t *testing.T) {
+	server := newTestServer(t, ServerConfig{IdempotencyCache: newTestCache(t)})
+
+	if rec := doRequest(server, http.MethodPost, "/users", `{"name":"J","email":"john@example.com"}`, "Idempotency-Key", "key-1"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if rec := doRequest(server, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`, "Idempotency-Key", "key-1"); rec.Code != http.StatusCreated {
+		t.Fatalf("expected the corrected retry to create, got %d", rec.Code)
+	}
+}
+
+// TestCreateUser_IdempotencyKeyCacheClosed tests that a cache error is answered with 500 instead of a panic
+func TestCreateUser_IdempotencyKeyCacheClosed(t *testing.T) {
+	idempotencyCache := newTestCache(t)
+	idempotencyCache.Close()
+	server := newTestServer(t, ServerConfig{IdempotencyCache: idempotencyCache})
+
+	rec := doRequest(server, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`, "Idempotency-Key", "key-1")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestCreateUser_IdempotencyKeyConcurrent tests that concurrent retries create the user only once
+func TestCreateUser_IdempotencyKeyConcurrent(t *testing.T) {
+	server := newTestServer(t, ServerConfig{IdempotencyCache: newTestCache(t)})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if rec := doRequest(server, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`, "Idempotency-Key", "key-1"); rec.Code != http.StatusCreated {
+				t.Errorf("expected 201, got %d", rec.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if count := userCount(t, server); count != 1 {
+		t.Errorf("expected 1 user, got %d", count)
+	}
+}
+
+// TestCreateUser_IdempotencyKeyBodyTooLarge tests that an oversized body is rejected before it is buffered whole
+func TestCreateUser_IdempotencyKeyBodyTooLarge(t *testing.T) {
+	server := newTestServer(t, ServerConfig{IdempotencyCache: newTestCache(t)})
+
+	body := `{"name":"John","email":"john@example.com","phone":"` + strings.Repeat("1", maxIdempotentBodyBytes) + `"}`
+	rec := doRequest(server, http.MethodPost, "/users", body, "Idempotency-Key", "key-1")
+	if apiErr := decodeError(t, rec); rec.Code != http.StatusRequestEntityTooLarge || apiErr.Error != "request_too_large" {
+		t.Errorf("expected 413, got %d %+v", rec.Code, apiErr)
+	}
+}
+
+// TestIdempotent_JoinedFailedFlight tests that a request with another body that joins a flight
+// whose response is not stored runs on its own instead of being rejected as a reused key
+func TestIdempotent_JoinedFailedFlight(t *testing.T) {
+	store := newTestCache(t)
+
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "bad" {
+			<-release
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+	send := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		idempotent(store, "key-1", rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(body)), handler)
+		return rec
+	}
+
+	bad := make(chan int)
+	go func() { bad <- send("bad").Code }()
+	time.Sleep(20 * time.Millisecond) // let the failing request start its flight
+
+	good := make(chan int)
+	go func() { good <- send("good").Code }()
+	time.Sleep(20 * time.Millisecond) // let the second request join it
+	close(release)
+
+	if code := <-bad; code != http.StatusBadRequest {
+		t.Errorf("expected 400 for the failing request, got %d", code)
+	}
+	if code := <-good; code != http.StatusCreated {
+		t.Errorf("expected the second request to run on its own and get 201, got %d", code)
+	}
+	if rec := send("bad"); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 against the stored 201, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UserID identifies a user. It is a string so generators are not limited to numbers, e.g. UUIDs.
+// Sequential IDs are decimal numbers and stay JSON numbers on the wire, so clients of the
+// default store see "id": 1 as before
+type UserID string
+
+// uuidPattern matches a UUID in its canonical lowercase form, as UUIDGenerator returns them
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// parseUserID parses a user ID path segment: a positive number (leading zeros are dropped,
+// so /users/05 is /users/5) or a UUID, which is lowercased
+func parseUserID(segment string) (UserID, error) {
+	if n, err := strconv.Atoi(segment); err == nil {
+		if n < 1 {
+			return "", fmt.Errorf("user ID must be positive")
+		}
+		return UserID(strconv.Itoa(n)), nil
+	}
+	if id := UserID(strings.ToLower(segment)); uuidPattern.MatchString(string(id)) {
+		return id, nil
+	}
+	return "", fmt.Errorf("user ID must be a positive number or a UUID")
+}
+
+// sequence returns the number of a sequential ID, ok is false for other IDs
+func (id UserID) sequence() (int, bool) {
+	n, err := strconv.Atoi(string(id))
+	return n, err == nil && n > 0 && strconv.Itoa(n) == string(id)
+}
+
+// less orders numeric IDs by value before any other IDs, which are ordered as strings
+func (id UserID) less(other UserID) bool {
+	a, aNumeric := id.sequence()
+	b, bNumeric := other.sequence()
+	switch {
+	case aNumeric && bNumeric:
+		return a < b
+	case aNumeric != bNumeric:
+		return aNumeric
+	}
+	return id < other
+}
+
+// MarshalJSON writes sequential IDs as numbers and any other ID as a string
+func (id UserID) MarshalJSON() ([]byte, error) {
+	if _, ok := id.sequence(); ok {
+		return []byte(id), nil
+	}
+	return json.Marshal(string(id))
+}
+
+// UnmarshalJSON accepts a number or a string
+func (id *UserID) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err == nil {
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("user ID %s is not an integer", n)
+		}
+		*id = UserID(n)
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("user ID must be a number or a string")
+	}
+	*id = UserID(s)
+	return nil
+}
+
+// IDGenerator hands out IDs for new users. UserStore calls Next with its write lock held,
+// so implementations don't need their own locking unless they are shared between stores
+type IDGenerator interface {
+	Next() UserID
+}
+
+// idRewinder is implemented by generators that can take back IDs handed out by a
+// rolled-back transaction, so the next create reuses them
+type idRewinder interface {
+	mark() int
+	rewind(mark int)
+}
+
+// SequentialIDGenerator is the default generator: 1, 2, 3, ...
+type SequentialIDGenerator struct {
+	last int
+}
+
+// Next returns the next sequential ID
+func (g *SequentialIDGenerator) Next() UserID {
+	g.last++
+	return UserID(strconv.Itoa(g.last))
+}
+
+func (g *SequentialIDGenerator) mark() int       { return g.last }
+func (g *SequentialIDGenerator) rewind(mark int) { g.last = mark }
+
+// UUIDGenerator hands out random version 4 UUIDs, so IDs don't reveal how many users exist
+type UUIDGenerator struct{}
+
+// Next returns a new random UUID
+func (UUIDGenerator) Next() UserID {
+	return UserID(newUUID())
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// stepIDGenerator hands out start, start+step, start+2*step, ...
+type stepIDGenerator struct {
+	next, step int
+}
+
+func (g *stepIDGenerator) Next() UserID {
+	id := g.next
+	g.next += g.step
+	return UserID(strconv.Itoa(id))
+}
+
+// TestUserStore_CustomIDGenerator tests that user IDs come from the injected generator
+func TestUserStore_CustomIDGenerator(t *testing.T) {
+	store := NewUserStoreWithIDGenerator(&stepIDGenerator{next: 1000, step: 10})
+
+	first, _ := store.Create("John", "john@example.com", "")
+	second, _ := store.Create("Jane", "jane@example.com", "")
+	if first.ID != "1000" || second.ID != "1010" {
+		t.Errorf("expected IDs 1000 and 1010, got %s and %s", first.ID, second.ID)
+	}
+	if user, exists := store.Get("1010"); !exists || user.Email != "jane@example.com" {
+		t.Errorf("user should be stored under the generated ID, got %+v", user)
+	}
+
+	// Transactions take their IDs from the same generator
+	store.Transaction(func(tx *Tx) error {
+		user, _ := tx.Create("Jim", "jim@example.com", "")
+		if user.ID != "1020" {
+			t.Errorf("expected ID 1020, got %s", user.ID)
+		}
+		return nil
+	})
+
+	// A generator handing out an ID already in use is an error, not an overwrite
+	repeating := NewUserStoreWithIDGenerator(&stepIDGenerator{next: 7, step: 0})
+	repeating.Create("John", "john@example.com", "")
+	if _, err := repeating.Create("Jane", "jane@example.com", ""); err == nil {
+		t.Error("expected an error for a duplicate generated ID")
+	}
+	if user, _ := repeating.Get("7"); user.Name != "John" {
+		t.Errorf("existing user must not be overwritten, got %+v", user)
+	}
+}
+
+// TestUserStore_UUIDGenerator tests a store handing out UUIDs, served and looked up through the handlers
+func TestUserStore_UUIDGenerator(t *testing.T) {
+	h := NewUserHandler(NewUserStoreWithIDGenerator(UUIDGenerator{}))
+
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"John Doe","email":"john@example.com"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID interface{} `json:"id"`
+	}
+	json.NewDecoder(rec.Body).Decode(&created)
+	id, ok := created.ID.(string)
+	if !ok || !uuidPattern.MatchString(id) {
+		t.Fatalf("expected a UUID string id, got %#v", created.ID)
+	}
+	if location := rec.Header().Get("Location"); location != "/users/"+id {
+		t.Errorf("expected Location /users/%s, got %q", id, location)
+	}
+
+	if rec := doRequest(h, http.MethodGet, "/users/"+id, ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for the UUID, got %d", rec.Code)
+	}
+	if rec := doRequest(h, http.MethodGet, "/users/not-a-uuid", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a malformed ID, got %d", rec.Code)
+	}
+}
+
+// TestUserID tests parsing, ordering and the JSON form of IDs
+func TestUserID(t *testing.T) {
+	parse := []struct {
+		segment string
+		want    UserID
+		valid   bool
+	}{
+		{"5", "5", true},
+		{"05", "5", true},
+		{"0", "", false},
+		{"-3", "", false},
+		{"abc", "", false},
+		{"3F2504E0-4F89-41D3-9A0C-0305E82C3301", "3f2504e0-4f89-41d3-9a0c-0305e82c3301", true},
+	}
+	for _, tt := range parse {
+		id, err := parseUserID(tt.segment)
+		if (err == nil) != tt.valid || id != tt.want {
+			t.Errorf("parseUserID(%q) = %q, %v, want %q (valid %v)", tt.segment, id, err, tt.want, tt.valid)
+		}
+	}
+
+	if !UserID("2").less("10") || UserID("10").less("2") || !UserID("10").less("0a") {
+		t.Error("numeric IDs should order by value and before other IDs")
+	}
+
+	data, _ := json.Marshal([]UserID{"7", "3f2504e0-4f89-41d3-9a0c-0305e82c3301"})
+	if string(data) != `[7,"3f2504e0-4f89-41d3-9a0c-0305e82c3301"]` {
+		t.Errorf("unexpected JSON %s", data)
+	}
+	var ids []UserID
+	if err := json.Unmarshal([]byte(`[7,"abc"]`), &ids); err != nil || len(ids) != 2 || ids[0] != "7" || ids[1] != "abc" {
+		t.Errorf("expected [7 abc], got %v (%v)", ids, err)
+	}
+	if err := json.Unmarshal([]byte(`[1.5]`), &ids); err == nil {
+		t.Error("expected an error for a fractional ID")
+	}
+}
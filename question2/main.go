@@ -1,14 +1,31 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
 	"regexp"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+
+	"question3/cache"
 )
 
 //prevent race condition
@@ -18,11 +35,44 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	XMLName       xml.Name   `json:"-" xml:"user"`
+	ID            UserID     `json:"id" xml:"id"`
+	Name          string     `json:"name" xml:"name"`
+	Email         string     `json:"email" xml:"email"`
+	Phone         string     `json:"phone,omitempty" xml:"phone,omitempty"`           // optional, E.164 format
+	Version       int        `json:"version" xml:"version"`                           // starts at 1, incremented on every update
+	EmailVerified bool       `json:"email_verified" xml:"email_verified"`             // reset to false whenever the email changes
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty"` // set by SoftDelete, nil for active users
+}
+
+// clone returns a copy of the user, the store only hands out copies so callers can read them
+// without holding the lock while later updates modify the stored user
+func (u *User) clone() *User {
+	c := *u
+	if u.DeletedAt != nil {
+		deletedAt := *u.DeletedAt
+		c.DeletedAt = &deletedAt
+	}
+	return &c
 }
 
+// canonicalEmail is the form emails are compared in for uniqueness: trimmed and lowercased,
+// so " John@Example.com" and "john@example.com" are the same address
+func canonicalEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+var (
+	// ErrEmailExists is returned when another user already has the email
+	ErrEmailExists = errors.New("email already exists")
+	// ErrUserNotFound is returned when a user does not exist or was soft-deleted
+	ErrUserNotFound = errors.New("user not found")
+	// ErrVersionConflict is returned when an update is based on a stale version of the user
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrStoreFull is returned by Create once the store holds its maximum number of users
+	ErrStoreFull = errors.New("user limit reached")
+)
+
 // UserStore manages user data with thread-safe operations
 // ini akan di akses bareng2 , maka perlu di lindungi dengan mutex
 // Goroutine 1 (POST /users)
@@ -34,205 +84,704 @@ type User struct {
 // Goroutine 4 (DELETE /users/3)
 
 type UserStore struct {
-	users  map[int]*User
-	nextID int
-	mu     sync.RWMutex
+	users    map[UserID]*User
+	ids      IDGenerator
+	maxUsers int // 0 means unlimited
+	mu       sync.RWMutex
+
+	subscribers []chan UserChange
+	closed      bool // set by Close, mutations fail afterwards
 }
 
 // NewUserStore creates a new UserStore instance
 func NewUserStore() *UserStore {
+	return NewUserStoreWithLimit(0)
+}
+
+// NewUserStoreWithLimit creates a UserStore holding at most maxUsers users, 0 means unlimited.
+// Soft-deleted users count towards the limit since they are kept and can be restored
+func NewUserStoreWithLimit(maxUsers int) *UserStore {
+	store := NewUserStoreWithIDGenerator(&SequentialIDGenerator{})
+	store.maxUsers = maxUsers
+	return store
+}
+
+// NewUserStoreWithIDGenerator creates a UserStore that takes new user IDs from ids
+func NewUserStoreWithIDGenerator(ids IDGenerator) *UserStore {
 	return &UserStore{
-		users:  make(map[int]*User),
-		nextID: 1,
+		users: make(map[UserID]*User),
+		ids:   ids,
 	}
 }
 
 // Create adds a new user to the store
-func (s *UserStore) Create(name, email string) (*User, error) {
+func (s *UserStore) Create(name, email, phone string) (*User, error) {
 	s.mu.Lock()
 	//s.mu.Lock() memastikan hanya 1 goroutine yang bisa menjalankan kode ini pada satu waktu
 	//Jadi tidak akan ada 2 user dengan ID yang sama
 	defer s.mu.Unlock()
 
-	log.Println("Current users before create:")
-	for id, u := range s.users {
-		log.Printf("  ID=%d: Name=%s, Email=%s\n", id, u.Name, u.Email)
+	return s.create(name, email, phone)
+}
+
+// GetOrCreate returns the active user with email, or creates one when the email is unused.
+// The bool reports whether the user was created. Lookup and insert share one write lock,
+// so concurrent callers with the same email all get the same user
+func (s *UserStore) GetOrCreate(name, email string) (*User, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, exists := s.emailOwner(email); exists && user.DeletedAt == nil {
+		return user.clone(), false, nil
 	}
-	//check if email already exists
-	for _, user := range s.users {
-		if user.Email == email {
-			return nil, fmt.Errorf("email already exists")
-		}
+
+	user, err := s.create(name, email, "")
+	if err != nil {
+		return nil, false, err
+	}
+	return user, true, nil
+}
+
+// create inserts a new user, the caller must hold the write lock
+func (s *UserStore) create(name, email, phone string) (*User, error) {
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	// checked under the write lock so concurrent creates can't overshoot the limit
+	if s.maxUsers > 0 && len(s.users) >= s.maxUsers {
+		return nil, ErrStoreFull
+	}
+
+	//check if email already exists, soft-deleted users keep their email reserved
+	email = strings.TrimSpace(email)
+	if _, exists := s.emailOwner(email); exists {
+		return nil, ErrEmailExists
+	}
+
+	id := s.ids.Next()
+	if _, exists := s.users[id]; exists {
+		return nil, fmt.Errorf("id generator returned id %s which is already in use", id)
 	}
 
 	user := &User{
-		ID:    s.nextID,
-		Name:  name,
-		Email: email,
+		ID:      id,
+		Name:    name,
+		Email:   email,
+		Phone:   phone,
+		Version: 1,
 	}
-	s.users[s.nextID] = user //← Multiple goroutines writing here
-	s.nextID++
-	log.Printf("Created user: %v\n", user)
+	s.users[id] = user                        //← Multiple goroutines writing here
+	log.Printf("Created user ID=%s", user.ID) // only the ID, names and emails don't belong in logs
+	s.publish(ChangeCreated, user)
 
-	return user, nil
+	return user.clone(), nil
 }
 
-// Get retrieves a user by ID
-func (s *UserStore) Get(id int) (*User, bool) {
+// Get retrieves a user by ID, soft-deleted users are not returned
+func (s *UserStore) Get(id UserID) (*User, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	user, exists := s.users[id]
-	return user, exists
+	if !exists || user.DeletedAt != nil {
+		return nil, false
+	}
+	return user.clone(), true
+}
+
+// List returns users ordered by ID in a new slice, soft-deleted users only when includeDeleted is set
+func (s *UserStore) List(includeDeleted bool) []*User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]*User, 0, len(s.users))
+	for _, user := range s.users {
+		if user.DeletedAt != nil && !includeDeleted {
+			continue
+		}
+		users = append(users, user.clone())
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID.less(users[j].ID) })
+	return users
+}
+
+// Count returns the number of active (not soft-deleted) users in the store
+func (s *UserStore) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, user := range s.users {
+		if user.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
 }
 
-// Update modifies an existing user
-func (s *UserStore) Update(id int, name, email string) (*User, bool) {
+// Update modifies an existing user. The bool reports whether the user exists, ErrEmailExists
+// is returned when another user, including a soft-deleted one, has the email
+func (s *UserStore) Update(id UserID, name, email, phone string) (*User, bool, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.closed {
+		return nil, false, ErrStoreClosed
+	}
 	user, exists := s.users[id]
-	if !exists {
-		return nil, false
+	if !exists || user.DeletedAt != nil {
+		return nil, false, nil
+	}
+	if err := s.update(user, name, email, phone); err != nil {
+		return nil, true, err
+	}
+	return user.clone(), true, nil
+}
+
+// update applies a full update to a stored user after checking the email is free,
+// the caller must hold the write lock
+func (s *UserStore) update(user *User, name, email, phone string) error {
+	email = strings.TrimSpace(email)
+	if owner, exists := s.emailOwner(email); exists && owner.ID != user.ID {
+		return ErrEmailExists
 	}
 
+	if canonicalEmail(user.Email) != canonicalEmail(email) {
+		user.EmailVerified = false
+	}
 	user.Name = name
 	user.Email = email
-	return user, true
+	user.Phone = phone
+	user.Version++
+	s.publish(ChangeUpdated, user)
+	return nil
 }
 
-// Delete removes a user from the store
-func (s *UserStore) Delete(id int) bool {
+// emailOwner returns the user, soft-deleted or not, whose email has the same canonical form
+// as email. The caller must hold the lock
+func (s *UserStore) emailOwner(email string) (*User, bool) {
+	canonical := canonicalEmail(email)
+	for _, user := range s.users {
+		if canonicalEmail(user.Email) == canonical {
+			return user, true
+		}
+	}
+	return nil, false
+}
+
+// UpdateIfVersion modifies an existing user only if its current version matches,
+// returning ErrVersionConflict otherwise so concurrent edits can't clobber each other
+func (s *UserStore) UpdateIfVersion(id UserID, name, email, phone string, version int) (*User, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, exists := s.users[id]
-	if !exists {
+	if s.closed {
+		return nil, ErrStoreClosed
+	}
+	user, exists := s.users[id]
+	if !exists || user.DeletedAt != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.Version != version {
+		return nil, ErrVersionConflict
+	}
+	if err := s.update(user, name, email, phone); err != nil {
+		return nil, err
+	}
+	return user.clone(), nil
+}
+
+// UpdateEmail changes only the email of an active user and marks it unverified.
+// The bool reports whether the user exists, ErrEmailExists is returned when another user,
+// including a soft-deleted one, has the email. Setting the current email again changes nothing
+func (s *UserStore) UpdateEmail(id UserID, email string) (*User, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, false, ErrStoreClosed
+	}
+	user, exists := s.users[id]
+	if !exists || user.DeletedAt != nil {
+		return nil, false, nil
+	}
+	email = strings.TrimSpace(email)
+	if canonicalEmail(user.Email) == canonicalEmail(email) {
+		return user.clone(), true, nil
+	}
+	if _, exists := s.emailOwner(email); exists {
+		return nil, true, ErrEmailExists
+	}
+
+	user.Email = email
+	user.EmailVerified = false
+	user.Version++
+	s.publish(ChangeUpdated, user)
+	return user.clone(), true, nil
+}
+
+// SoftDelete marks a user as deleted while keeping it in the store for audit history.
+// The email stays reserved so a later Restore can't create a duplicate
+func (s *UserStore) SoftDelete(id UserID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists || user.DeletedAt != nil || s.closed {
+		return false
+	}
+
+	now := time.Now()
+	user.DeletedAt = &now
+	s.publish(ChangeDeleted, user)
+	return true
+}
+
+// Restore clears the soft-delete flag of a user
+func (s *UserStore) Restore(id UserID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists || user.DeletedAt == nil || s.closed {
+		return false
+	}
+
+	user.DeletedAt = nil
+	s.publish(ChangeUpdated, user)
+	return true
+}
+
+// Delete permanently removes a user from the store
+func (s *UserStore) Delete(id UserID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, exists := s.users[id]
+	if !exists || s.closed {
 		return false
 	}
 
 	delete(s.users, id)
+	s.publish(ChangeDeleted, user)
 	return true
 }
 
 // APIError represents an error response
 type APIError struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	XMLName xml.Name `json:"-" xml:"error"`
+	Error   string   `json:"error" xml:"error"`
+	Message string   `json:"message,omitempty" xml:"message,omitempty"`
 }
 
 // CreateUserRequest represents the request body for creating a user
 type CreateUserRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Phone string `json:"phone"`
 }
 
-// UpdateUserRequest represents the request body for updating a user
-type UpdateUserRequest struct {
-	Name  string `json:"name"`
+// BatchGetRequest represents the request body for fetching several users by ID
+type BatchGetRequest struct {
+	IDs []UserID `json:"ids"`
+}
+
+// BatchGetResponse holds the users found by a batch get and the IDs that were not found
+type BatchGetResponse struct {
+	Users   []*User  `json:"users"`
+	Missing []UserID `json:"missing"`
+}
+
+// ValidationResult is the response of a dry-run validation, Errors maps field names to messages
+type ValidationResult struct {
+	Valid  bool              `json:"valid"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// UpdateEmailRequest represents the request body of PATCH /users/:id/email
+type UpdateEmailRequest struct {
 	Email string `json:"email"`
 }
 
+// maxBatchGetIDs caps how many IDs a single batch get may request
+const maxBatchGetIDs = 100
+
+// UpdateUserRequest represents the request body for updating a user.
+// When Version is set the update only succeeds if it matches the stored version
+type UpdateUserRequest struct {
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Phone   string `json:"phone"`
+	Version *int   `json:"version,omitempty"`
+}
+
 var (
 	// Email validation regex
 	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+	// E.164 phone regex, "+" then a country code that doesn't start with 0, at most 15 digits in total
+	phoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
 )
 
-// validateName validates user name
-func validateName(name string) error {
+// normalizeName trims the name and collapses runs of internal whitespace into a single space
+func normalizeName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// validateName validates user name against the configured length bounds, length is counted
+// in runes so multibyte names aren't penalized
+func (v ValidationConfig) validateName(name string) error {
 	name = strings.TrimSpace(name)
+	for _, r := range name {
+		// Cf covers invisible format characters such as zero-width spaces
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return fmt.Errorf("name must not contain control or invisible characters")
+		}
+	}
+
+	name = normalizeName(name)
 	if name == "" {
 		return fmt.Errorf("name is required")
 	}
-	if len(name) < 2 {
-		return fmt.Errorf("name must be at least 2 characters long")
+	minLength, maxLength := v.nameBounds()
+	length := utf8.RuneCountInString(name)
+	if length < minLength {
+		return fmt.Errorf("name must be at least %d characters long", minLength)
 	}
-	if len(name) > 100 {
-		return fmt.Errorf("name must not exceed 100 characters")
+	if length > maxLength && !v.TruncateNames {
+		return fmt.Errorf("name must not exceed %d characters", maxLength)
 	}
 	return nil
 }
 
-// validateEmail validates user email
-func validateEmail(email string) error {
+// validatePhone validates an optional phone number, empty is valid
+func validatePhone(phone string) error {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return nil
+	}
+	if !phoneRegex.MatchString(phone) {
+		return fmt.Errorf("phone must be in E.164 format, e.g. +6281234567890")
+	}
+	return nil
+}
+
+// ValidationConfig holds the configurable rules applied to user input
+type ValidationConfig struct {
+	// AllowedDomains restricts emails to these domains, empty allows every domain
+	AllowedDomains []string
+	// BlockedDomains rejects emails from these domains
+	BlockedDomains []string
+	// DisposableDomains rejects throwaway email providers, the list is supplied by the caller
+	DisposableDomains []string
+	// MinNameLength and MaxNameLength bound the name length in runes, zero uses the defaults
+	MinNameLength int
+	MaxNameLength int
+	// TruncateNames cuts names over the maximum length down to it instead of rejecting them
+	TruncateNames bool
+	// MaxEmailLength caps the whole address in bytes, zero uses the RFC 5321 limit of 254
+	MaxEmailLength int
+	// AllowIDN accepts internationalized domains such as münchen.de by checking their punycode form
+	AllowIDN bool
+}
+
+const (
+	defaultMinNameLength  = 2
+	defaultMaxNameLength  = 100
+	defaultMaxEmailLength = 254
+	maxEmailLocalLength   = 64
+)
+
+// nameBounds returns the configured name length bounds, falling back to the defaults
+func (v ValidationConfig) nameBounds() (int, int) {
+	minLength, maxLength := v.MinNameLength, v.MaxNameLength
+	if minLength <= 0 {
+		minLength = defaultMinNameLength
+	}
+	if maxLength <= 0 {
+		maxLength = defaultMaxNameLength
+	}
+	return minLength, maxLength
+}
+
+// storedName normalizes a validated name into the form that is stored, truncating it at a
+// rune boundary when TruncateNames is set
+func (v ValidationConfig) storedName(name string) string {
+	name = normalizeName(name)
+	if !v.TruncateNames {
+		return name
+	}
+	_, maxLength := v.nameBounds()
+	count := 0
+	for i := range name {
+		if count == maxLength {
+			return strings.TrimRight(name[:i], " ")
+		}
+		count++
+	}
+	return name
+}
+
+// storedEmail normalizes a validated email into the form that is stored and compared for
+// uniqueness: trimmed, and with AllowIDN the domain in punycode, so john@münchen.de and
+// john@xn--mnchen-3ya.de are the same address
+func (v ValidationConfig) storedEmail(email string) string {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndex(email, "@")
+	if !v.AllowIDN || at < 0 {
+		return email
+	}
+	ascii, err := idna.Lookup.ToASCII(email[at+1:])
+	if err != nil {
+		return email // rejected by validateEmail
+	}
+	return email[:at+1] + ascii
+}
+
+// validateEmail validates user email length and format, then the disposable and allow/deny domain lists.
+// With AllowIDN the domain is converted to punycode first, so the lists match its xn-- form
+func (v ValidationConfig) validateEmail(email string) error {
 	email = strings.TrimSpace(email)
 	if email == "" {
 		return fmt.Errorf("email is required")
 	}
+
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return fmt.Errorf("invalid email format")
+	}
+	local, domain := email[:at], email[at+1:]
+	if v.AllowIDN {
+		ascii, err := idna.Lookup.ToASCII(domain)
+		if err != nil {
+			return fmt.Errorf("invalid email domain")
+		}
+		domain = ascii
+		email = local + "@" + domain
+	}
+
+	if len(local) > maxEmailLocalLength {
+		return fmt.Errorf("email local part must not exceed %d characters", maxEmailLocalLength)
+	}
+	maxLength := v.MaxEmailLength
+	if maxLength <= 0 {
+		maxLength = defaultMaxEmailLength
+	}
+	if len(email) > maxLength {
+		return fmt.Errorf("email must not exceed %d characters", maxLength)
+	}
 	if !emailRegex.MatchString(email) {
 		return fmt.Errorf("invalid email format")
 	}
+
+	if containsDomain(v.DisposableDomains, domain) {
+		return fmt.Errorf("disposable email addresses are not allowed")
+	}
+	if containsDomain(v.BlockedDomains, domain) {
+		return fmt.Errorf("email domain not allowed")
+	}
+	if len(v.AllowedDomains) > 0 && !containsDomain(v.AllowedDomains, domain) {
+		return fmt.Errorf("email domain not allowed")
+	}
 	return nil
 }
 
-// respondWithJSON sends a JSON response
-func respondWithJSON(w http.ResponseWriter, status int, data interface{}) {
+// containsDomain reports whether domain is in the list, ignoring case
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithJSON sends a JSON response, compact unless the request asks for ?pretty=true
+func respondWithJSON(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	encoder := json.NewEncoder(w)
+	if wantsPretty(r) {
+		encoder.SetIndent("", "  ")
+	}
+	encoder.Encode(data)
 }
 
-// respondWithError sends an error response
-func respondWithError(w http.ResponseWriter, status int, error, message string) {
-	respondWithJSON(w, status, APIError{
+// wantsPretty reports whether the client asked for indented output with ?pretty=true
+func wantsPretty(r *http.Request) bool {
+	return r.URL.Query().Get("pretty") == "true"
+}
+
+// respondWith sends data as XML when the client asks for application/xml, otherwise as JSON.
+// Data that has no XML representation (e.g. maps) is always sent as JSON
+func respondWith(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	if !prefersXML(r) {
+		respondWithJSON(w, r, status, data)
+		return
+	}
+
+	marshal := xml.Marshal
+	if wantsPretty(r) {
+		marshal = func(v interface{}) ([]byte, error) { return xml.MarshalIndent(v, "", "  ") }
+	}
+	body, err := marshal(data)
+	if err != nil {
+		respondWithJSON(w, r, status, data)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
+
+// prefersXML reports whether the Accept header asks for XML before JSON.
+// An absent Accept header or */* means JSON
+func prefersXML(r *http.Request) bool {
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(mediaRange, ";", 2)[0])
+		switch mediaType {
+		case "application/json":
+			return false
+		case "application/xml", "text/xml":
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithError sends an error response in the format the client accepts
+func respondWithError(w http.ResponseWriter, r *http.Request, status int, error, message string) {
+	respondWith(w, r, status, APIError{
 		Error:   error,
 		Message: message,
 	})
 }
 
+// decodeErrorMessage turns a JSON decode error into a message for the client: an empty body,
+// a truncated one, the byte offset of a syntax error or the field with the wrong type
+func decodeErrorMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "Request body is required"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return "Invalid JSON payload: body ends unexpectedly"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("Invalid JSON payload at byte %d: %s", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return fmt.Sprintf("Invalid JSON payload: %s must be %s, got %s", typeErr.Field, jsonTypeName(typeErr.Type), typeErr.Value)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("Invalid JSON payload: expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)
+	}
+	return "Invalid JSON payload"
+}
+
+// jsonTypeName names a Go type the way a JSON client thinks of it
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	}
+	return "an object"
+}
+
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
-	store *UserStore
+	store           Store
+	validation      ValidationConfig
+	bulkConcurrency int             // items of a bulk create processed at once, 0 uses defaultBulkConcurrency
+	idempotency     *cache.TTLCache // created responses by Idempotency-Key, nil ignores the header
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(store *UserStore) *UserHandler {
-	return &UserHandler{store: store}
+func NewUserHandler(store Store) *UserHandler {
+	return NewUserHandlerWithConfig(store, ValidationConfig{})
 }
 
-// CreateUser handles POST /users
+// NewUserHandlerWithConfig creates a new UserHandler with custom validation rules
+func NewUserHandlerWithConfig(store Store, validation ValidationConfig) *UserHandler {
+	return &UserHandler{store: store, validation: validation}
+}
+
+// CreateUser handles POST /users. With an Idempotency-Key header a retried request gets
+// the original 201 response instead of creating the user again
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
 		return
 	}
 
+	if key := r.Header.Get("Idempotency-Key"); key != "" && h.idempotency != nil {
+		idempotent(h.idempotency, key, w, r, h.createUser)
+		return
+	}
+	h.createUser(w, r)
+}
+
+// createUser validates the request body and creates the user
+func (h *UserHandler) createUser(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
 		return
 	}
 
 	// Validate name
-	if err := validateName(req.Name); err != nil {
-		respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+	if err := h.validation.validateName(req.Name); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
 
 	// Validate email
-	if err := validateEmail(req.Email); err != nil {
-		respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+	if err := h.validation.validateEmail(req.Email); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	// Validate phone
+	if err := validatePhone(req.Phone); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
 
 	// Create user
-	user, err := h.store.Create(strings.TrimSpace(req.Name), strings.TrimSpace(req.Email))
+	user, err := h.store.Create(h.validation.storedName(req.Name), h.validation.storedEmail(req.Email), strings.TrimSpace(req.Phone))
 	if err != nil {
-		if err.Error() == "email already exists" {
-			respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+		if errors.Is(err, ErrEmailExists) {
+			respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
 			return
 		}
-		respondWithError(w, http.StatusInternalServerError, "internal_error", "Failed to create user")
+		if errors.Is(err, ErrStoreFull) {
+			respondWithError(w, r, http.StatusForbidden, "forbidden", err.Error())
+			return
+		}
+		if errors.Is(err, ErrStoreClosed) {
+			respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+			return
+		}
+		respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to create user")
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, user)
+	w.Header().Set("Location", userLocation(user.ID))
+	respondWith(w, r, http.StatusCreated, user)
 }
 
-// GetUser handles GET /users/:id
+// GetUser handles GET /users/:id, ?fields=id,name returns only the selected fields as JSON
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
 		return
 	}
 
@@ -241,146 +790,527 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	// Extract ID from URL
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) != 2 {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid URL format")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid URL format")
+		return
+	}
+
+	id, err := parseUserID(pathParts[1])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid user ID")
+		return
+	}
+	fields, err := parseFields(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	user, exists := h.store.Get(id)
+	if !exists {
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
+		return
+	}
+
+	etag := userETag(user)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if fields != nil {
+		respondWithJSON(w, r, http.StatusOK, projectUser(user, fields))
+		return
+	}
+	respondWith(w, r, http.StatusOK, user)
+}
+
+// HeadUser handles HEAD /users/:id, reporting whether the user exists with the status code only
+func (h *UserHandler) HeadUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 2 {
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
-	id, err := strconv.Atoi(pathParts[1])
+	id, err := parseUserID(pathParts[1])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid user ID")
+		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
 
 	user, exists := h.store.Get(id)
 	if !exists {
-		respondWithError(w, http.StatusNotFound, "not_found", "User not found")
+		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, user)
+	w.Header().Set("ETag", userETag(user))
+	w.WriteHeader(http.StatusOK)
+}
+
+// userETag computes a weak ETag from the serialized user, so any field change produces a new tag
+func userETag(user *User) string {
+	body, _ := json.Marshal(user)
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
 		return
 	}
 
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) != 3 {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid URL format")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid URL format")
 		return
 	}
 
 	email := strings.TrimSpace(pathParts[2])
 	if email == "" {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid email")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid email")
 		return
 	}
-	user, exists := h.store.FindByEmail(strings.TrimSpace(email))
+	user, exists := h.store.FindByEmail(h.validation.storedEmail(email))
 	if !exists {
-		respondWithError(w, http.StatusNotFound, "not_found", "User not found")
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
 		return
 	}
-	respondWithJSON(w, http.StatusOK, user)
+	respondWith(w, r, http.StatusOK, user)
+}
+
+// CountUsers handles GET /users/count
+func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, map[string]int{"count": h.store.Count()})
 }
 
 // UpdateUser handles PUT /users/:id
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
-		respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only PUT method is allowed")
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only PUT method is allowed")
 		return
 	}
 
 	// Extract ID from URL
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) != 2 {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid URL format")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid URL format")
 		return
 	}
 
-	id, err := strconv.Atoi(pathParts[1])
+	id, err := parseUserID(pathParts[1])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid user ID")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid user ID")
 		return
 	}
 
 	var req UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON payload")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
 		return
 	}
 
 	// Validate name
-	if err := validateName(req.Name); err != nil {
-		respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+	if err := h.validation.validateName(req.Name); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
 
 	// Validate email
-	if err := validateEmail(req.Email); err != nil {
-		respondWithError(w, http.StatusBadRequest, "validation_error", err.Error())
+	if err := h.validation.validateEmail(req.Email); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
 
-	// Update user
-	user, exists := h.store.Update(id, strings.TrimSpace(req.Name), strings.TrimSpace(req.Email))
-	if !exists {
-		respondWithError(w, http.StatusNotFound, "not_found", "User not found")
+	// Validate phone
+	if err := validatePhone(req.Phone); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	// Update user, guarded by the version when the client sent one
+	if req.Version != nil {
+		user, err := h.store.UpdateIfVersion(id, h.validation.storedName(req.Name), h.validation.storedEmail(req.Email), strings.TrimSpace(req.Phone), *req.Version)
+		switch {
+		case errors.Is(err, ErrUserNotFound):
+			respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
+		case errors.Is(err, ErrVersionConflict):
+			respondWithError(w, r, http.StatusConflict, "version_conflict", "User was modified by another request, fetch it and retry")
+		case errors.Is(err, ErrEmailExists):
+			respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		case errors.Is(err, ErrStoreClosed):
+			respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+		case err != nil:
+			respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to update user")
+		default:
+			respondWith(w, r, http.StatusOK, user)
+		}
+		return
+	}
+
+	user, exists, err := h.store.Update(id, h.validation.storedName(req.Name), h.validation.storedEmail(req.Email), strings.TrimSpace(req.Phone))
+	switch {
+	case errors.Is(err, ErrEmailExists):
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrStoreClosed):
+		respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to update user")
+	case !exists:
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
+	default:
+		respondWith(w, r, http.StatusOK, user)
+	}
+}
+
+// userSorters maps the ?sort= keys to an ascending comparison, ties fall back to ID
+var userSorters = map[string]func(a, b *User) bool{
+	"id": func(a, b *User) bool { return a.ID.less(b.ID) },
+	"name": func(a, b *User) bool {
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID.less(b.ID)
+	},
+	"email": func(a, b *User) bool {
+		if a.Email != b.Email {
+			return a.Email < b.Email
+		}
+		return a.ID.less(b.ID)
+	},
+}
+
+// ListUsers handles GET /users, ?include_deleted=true also returns soft-deleted users
+// and ?sort=name|email|id sorts the result, a "-" prefix sorts descending.
+// ?fields=id,name returns only the selected fields of each user
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+
+	sortKey := r.URL.Query().Get("sort")
+	descending := strings.HasPrefix(sortKey, "-")
+	sortKey = strings.TrimPrefix(sortKey, "-")
+	if sortKey == "" {
+		sortKey = "id"
+	}
+	less, ok := userSorters[sortKey]
+	if !ok {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid sort key, use id, name or email with an optional - prefix")
+		return
+	}
+
+	fields, err := parseFields(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	includeDeleted := r.URL.Query().Get("include_deleted") == "true"
+	users := h.store.List(includeDeleted) // a fresh slice, sorting it doesn't touch the store
+	sort.Slice(users, func(i, j int) bool {
+		if descending {
+			return less(users[j], users[i])
+		}
+		return less(users[i], users[j])
+	})
+
+	if fields != nil {
+		respondWithJSON(w, r, http.StatusOK, projectUsers(users, fields))
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, users)
+}
+
+// ValidateUser handles POST /users/validate, running the create validation without persisting.
+// Every field is checked so the client can show all errors at once
+func (h *UserHandler) ValidateUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+
+	fieldErrors := map[string]string{}
+	if err := h.validation.validateName(req.Name); err != nil {
+		fieldErrors["name"] = err.Error()
+	}
+	if err := h.validation.validateEmail(req.Email); err != nil {
+		fieldErrors["email"] = err.Error()
+	} else if _, exists := h.store.FindByEmail(h.validation.storedEmail(req.Email)); exists {
+		fieldErrors["email"] = ErrEmailExists.Error()
+	}
+	if err := validatePhone(req.Phone); err != nil {
+		fieldErrors["phone"] = err.Error()
+	}
+
+	if len(fieldErrors) > 0 {
+		respondWith(w, r, http.StatusBadRequest, ValidationResult{Valid: false, Errors: fieldErrors})
+		return
+	}
+	respondWith(w, r, http.StatusOK, ValidationResult{Valid: true})
+}
+
+// GetOrCreateUser handles POST /users/get-or-create, returning the user with the given email
+// (200) or creating it (201)
+func (h *UserHandler) GetOrCreateUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
+		return
+	}
+
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+	if err := h.validation.validateName(req.Name); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	if err := h.validation.validateEmail(req.Email); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	user, created, err := h.store.GetOrCreate(h.validation.storedName(req.Name), h.validation.storedEmail(req.Email))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrEmailExists):
+			// the email belongs to a soft-deleted user
+			respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		case errors.Is(err, ErrStoreFull):
+			respondWithError(w, r, http.StatusForbidden, "forbidden", err.Error())
+		case errors.Is(err, ErrStoreClosed):
+			respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to create user")
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+		w.Header().Set("Location", userLocation(user.ID))
+	}
+	respondWith(w, r, status, user)
+}
+
+// BatchGetUsers handles POST /users/batch-get, returning the found users and the missing IDs
+func (h *UserHandler) BatchGetUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST method is allowed")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, user)
+	var req BatchGetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+	if len(req.IDs) == 0 {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", "ids is required")
+		return
+	}
+	if len(req.IDs) > maxBatchGetIDs {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", fmt.Sprintf("at most %d ids are allowed", maxBatchGetIDs))
+		return
+	}
+
+	users, missing := h.store.GetMany(req.IDs)
+	respondWith(w, r, http.StatusOK, BatchGetResponse{Users: users, Missing: missing})
 }
 
-// DeleteUser handles DELETE /users/:id
+// DeleteUser handles DELETE /users/:id by soft-deleting the user
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
-		respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only DELETE method is allowed")
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only DELETE method is allowed")
 		return
 	}
 
 	// Extract ID from URL
 	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(pathParts) != 2 {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid URL format")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid URL format")
 		return
 	}
 
-	id, err := strconv.Atoi(pathParts[1])
+	id, err := parseUserID(pathParts[1])
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "invalid_request", "Invalid user ID")
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid user ID")
 		return
 	}
 
-	if !h.store.Delete(id) {
-		respondWithError(w, http.StatusNotFound, "not_found", "User not found")
+	if !h.store.SoftDelete(id) {
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, map[string]string{"message": "User deleted successfully"})
+	respondWithJSON(w, r, http.StatusOK, map[string]string{"message": "User deleted successfully"})
 }
 
-// find user by email
-func (s *UserStore) FindByEmail(email string) (*User, bool) {
+// UpdateUserEmail handles PATCH /users/:id/email, changing only the email and resetting
+// email_verified so the new address has to be verified again
+func (h *UserHandler) UpdateUserEmail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only PATCH method is allowed")
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	id, err := parseUserID(pathParts[1])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid user ID")
+		return
+	}
+
+	var req UpdateEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+	if err := h.validation.validateEmail(req.Email); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+
+	user, exists, err := h.store.UpdateEmail(id, h.validation.storedEmail(req.Email))
+	switch {
+	case errors.Is(err, ErrEmailExists):
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrStoreClosed):
+		respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to update email")
+	case !exists:
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
+	default:
+		respondWith(w, r, http.StatusOK, user)
+	}
+}
+
+// GetMany retrieves several active users under a single read lock. Found users keep the order
+// of ids and missing (or soft-deleted) IDs are returned separately, duplicate IDs are reported once
+func (s *UserStore) GetMany(ids []UserID) ([]*User, []UserID) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	for _, user := range s.users {
-		if user.Email == email {
-			return user, true
+	found, missing := []*User{}, []UserID{}
+	seen := make(map[UserID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if user, exists := s.users[id]; exists && user.DeletedAt == nil {
+			found = append(found, user.clone())
+		} else {
+			missing = append(missing, id)
 		}
 	}
+	return found, missing
+}
+
+// FindByEmail retrieves an active user by email, compared in canonical form
+func (s *UserStore) FindByEmail(email string) (*User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if user, exists := s.emailOwner(email); exists && user.DeletedAt == nil {
+		return user.clone(), true
+	}
 	return nil, false
 }
 
-// Router handles routing logic
+// userLocation is the path of a user resource, sent in the Location header of 201 responses
+func userLocation(id UserID) string {
+	return "/users/" + string(id)
+}
+
+// ServeHTTP makes UserHandler an http.Handler by dispatching through Router
+func (h *UserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Router(w, r)
+}
+
+// Router handles routing logic. Duplicate slashes are already cleaned by CleanPathMiddleware,
+// a trailing slash is trimmed here so /users/5/ resolves like /users/5
 func (h *UserHandler) Router(w http.ResponseWriter, r *http.Request) {
+	// handlers split r.URL.Path themselves, so trim it in place before dispatching
+	if len(r.URL.Path) > 1 {
+		r.URL.Path = strings.TrimSuffix(r.URL.Path, "/")
+	}
 	path := r.URL.Path
 
-	// POST /users
-	if path == "/users" && r.Method == http.MethodPost {
-		h.CreateUser(w, r)
+	// GET, POST /users
+	if path == "/users" {
+		switch r.Method {
+		case http.MethodGet:
+			h.ListUsers(w, r)
+		case http.MethodPost:
+			h.CreateUser(w, r)
+		default:
+			respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+		}
+		return
+	}
+
+	// GET /users/count, must be matched before the /users/:id catch-all
+	if path == "/users/count" {
+		h.CountUsers(w, r)
+		return
+	}
+
+	// POST /users/batch-get, must be matched before the /users/:id catch-all
+	if path == "/users/batch-get" {
+		h.BatchGetUsers(w, r)
+		return
+	}
+
+	// POST /users/bulk
+	if path == "/users/bulk" {
+		h.BulkCreateUsers(w, r)
+		return
+	}
+
+	// POST /users/validate
+	if path == "/users/validate" {
+		h.ValidateUser(w, r)
+		return
+	}
+
+	// POST /users/get-or-create
+	if path == "/users/get-or-create" {
+		h.GetOrCreateUser(w, r)
 		return
 	}
 
@@ -390,41 +1320,103 @@ func (h *UserHandler) Router(w http.ResponseWriter, r *http.Request) {
 		case http.MethodGet:
 			h.GetUserByEmail(w, r)
 		default:
-			respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 		}
 		return
 	}
 
-	// GET, PUT, DELETE /users/:id
+	// PATCH /users/:id/email
+	if parts := strings.Split(strings.Trim(path, "/"), "/"); len(parts) == 3 && parts[0] == "users" && parts[2] == "email" {
+		h.UpdateUserEmail(w, r)
+		return
+	}
+
+	// GET, HEAD, PUT, PATCH, DELETE /users/:id
 	if strings.HasPrefix(path, "/users/") {
 		log.Println("get user")
 		switch r.Method {
 		case http.MethodGet:
 			h.GetUser(w, r)
+		case http.MethodHead:
+			h.HeadUser(w, r)
 		case http.MethodPut:
 			h.UpdateUser(w, r)
+		case http.MethodPatch:
+			h.PatchUser(w, r)
 		case http.MethodDelete:
 			h.DeleteUser(w, r)
 		default:
-			respondWithError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
+			respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed")
 		}
 		return
 	}
 
 	log.Println("path", path)
 
-	respondWithError(w, http.StatusNotFound, "not_found", "Endpoint not found")
+	respondWithError(w, r, http.StatusNotFound, "not_found", "Endpoint not found")
 }
 
+// shutdownTimeout bounds how long a graceful shutdown waits for in-flight requests
+const shutdownTimeout = 10 * time.Second
+
 func main() {
-	store := NewUserStore()
-	handler := NewUserHandler(store)
+	// In memory by default, DATABASE_PATH switches to a durable SQLite database
+	var store Store = NewUserStore()
+	if path := os.Getenv("DATABASE_PATH"); path != "" {
+		sqliteStore, err := NewSQLiteStore(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store = sqliteStore
+	} else if path := os.Getenv("USERS_FILE"); path != "" {
+		// seed the in-memory store, refusing to start on a file with duplicate IDs or emails
+		fileStore, err := NewUserStoreFromFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		store = fileStore
+	}
 
-	http.HandleFunc("/", handler.Router)
+	ttlCache := cache.NewTTLCache(5 * time.Minute)
+	defer ttlCache.Stop()
+	idempotencyCache := cache.NewTTLCache(idempotencyTTL)
+	defer idempotencyCache.Stop()
+
+	cfg := ServerConfig{
+		IdempotencyCache: idempotencyCache,
+		AllowAdminReset:  os.Getenv("ALLOW_ADMIN_RESET") == "true",
+	}
+	if cfg.AllowAdminReset {
+		log.Println("ALLOW_ADMIN_RESET is set, POST /admin/reset and /admin/seed are enabled")
+	}
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		cfg.AllowedOrigins = strings.Split(origins, ",")
+	}
 
 	port := ":8080"
-	fmt.Printf("Server starting on port %s...\n", port)
-	if err := http.ListenAndServe(port, nil); err != nil {
-		log.Fatal(err)
+	server := &http.Server{Addr: port, Handler: NewServer(store, ttlCache, cfg)}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		fmt.Printf("Server starting on port %s...\n", port)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.Print(err)
+	case <-ctx.Done():
+		// let in-flight requests finish, then close the store so subscribers' range loops end
+		log.Print("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		log.Printf("Closing store: %v", err)
 	}
 }
@@ -0,0 +1,1014 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+)
+
+// doRequest sends a JSON request to handler, with header given as name, value pairs,
+// and returns the recorded response
+func doRequest(handler http.Handler, method, path, body string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeError decodes an APIError response body
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) APIError {
+	t.Helper()
+	var apiErr APIError
+	if err := json.NewDecoder(rec.Body).Decode(&apiErr); err != nil {
+		t.Fatalf("invalid error body: %v", err)
+	}
+	return apiErr
+}
+
+// TestCreateUser_EmailDomainPolicy tests the allowed and blocked email domain lists
+func TestCreateUser_EmailDomainPolicy(t *testing.T) {
+	h := NewUserHandlerWithConfig(NewUserStore(), ValidationConfig{
+		AllowedDomains: []string{"corp.com", "spam.com"},
+		BlockedDomains: []string{"spam.com"},
+	})
+
+	// Allowed domain
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@Corp.com"}`)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("allowed domain: expected 201, got %d", rec.Code)
+	}
+
+	// Blocked domain wins even if it is also allowed
+	rec = doRequest(h, http.MethodPost, "/users", `{"name":"Jane","email":"jane@spam.com"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("blocked domain: expected 400, got %d", rec.Code)
+	}
+	if msg := decodeError(t, rec).Message; msg != "email domain not allowed" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	// Domain outside the allow list
+	rec = doRequest(h, http.MethodPost, "/users", `{"name":"Jim","email":"jim@other.com"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unlisted domain: expected 400, got %d", rec.Code)
+	}
+}
+
+// TestCreateUser_DefaultAllowsAllDomains tests that the default config accepts any domain
+func TestCreateUser_DefaultAllowsAllDomains(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@anything.io"}`)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rec.Code)
+	}
+}
+
+// TestCountUsers tests the count endpoint is not swallowed by the /users/:id route
+func TestCountUsers(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+	doRequest(h, http.MethodPost, "/users", `{"name":"Jane","email":"jane@example.com"}`)
+
+	rec := doRequest(h, http.MethodGet, "/users/count", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var body map[string]int
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["count"] != 2 {
+		t.Errorf("expected count 2, got %d", body["count"])
+	}
+}
+
+// TestSoftDelete tests that soft-deleted users are hidden by default and can be restored
+func TestSoftDelete(t *testing.T) {
+	store := NewUserStore()
+	h := NewUserHandler(store)
+	doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+	doRequest(h, http.MethodPost, "/users", `{"name":"Jane","email":"jane@example.com"}`)
+
+	rec := doRequest(h, http.MethodDelete, "/users/1", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	if rec := doRequest(h, http.MethodGet, "/users/1", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("soft-deleted user should be 404, got %d", rec.Code)
+	}
+	if store.Count() != 1 {
+		t.Errorf("expected count 1, got %d", store.Count())
+	}
+
+	var users []User
+	rec = doRequest(h, http.MethodGet, "/users", "")
+	json.NewDecoder(rec.Body).Decode(&users)
+	if len(users) != 1 || users[0].ID != "2" {
+		t.Errorf("list should only contain user 2, got %+v", users)
+	}
+
+	rec = doRequest(h, http.MethodGet, "/users?include_deleted=true", "")
+	json.NewDecoder(rec.Body).Decode(&users)
+	if len(users) != 2 || users[0].DeletedAt == nil {
+		t.Errorf("include_deleted should reveal user 1 with deleted_at, got %+v", users)
+	}
+
+	if !store.Restore("1") {
+		t.Fatal("Restore should succeed for a soft-deleted user")
+	}
+	if _, exists := store.Get("1"); !exists {
+		t.Error("restored user should be visible again")
+	}
+
+	// Hard delete is still available
+	if !store.Delete("1") {
+		t.Error("hard Delete should remove the user")
+	}
+	if store.Restore("1") {
+		t.Error("a hard-deleted user can't be restored")
+	}
+}
+
+// TestUpdateUser_StaleVersion tests that an update with an outdated version is rejected
+func TestUpdateUser_StaleVersion(t *testing.T) {
+	store := NewUserStore()
+	h := NewUserHandler(store)
+	doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+
+	// First client updates with the current version
+	rec := doRequest(h, http.MethodPut, "/users/1", `{"name":"John A","email":"john@example.com","version":1}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var user User
+	json.NewDecoder(rec.Body).Decode(&user)
+	if user.Version != 2 {
+		t.Errorf("expected version 2, got %d", user.Version)
+	}
+
+	// Second client still holds version 1
+	rec = doRequest(h, http.MethodPut, "/users/1", `{"name":"John B","email":"john@example.com","version":1}`)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rec.Code)
+	}
+
+	if _, err := store.UpdateIfVersion("1", "John C", "john@example.com", "", 1); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict, got %v", err)
+	}
+	if current, _ := store.Get("1"); current.Name != "John A" {
+		t.Errorf("stale updates must not be applied, name is %s", current.Name)
+	}
+}
+
+// TestListUsers_Sort tests every sort key in both directions
+func TestListUsers_Sort(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	doRequest(h, http.MethodPost, "/users", `{"name":"Charlie","email":"alpha@example.com"}`)
+	doRequest(h, http.MethodPost, "/users", `{"name":"Alice","email":"charlie@example.com"}`)
+	doRequest(h, http.MethodPost, "/users", `{"name":"Bob","email":"bravo@example.com"}`)
+
+	tests := []struct {
+		sort string
+		want []UserID
+	}{
+		{"", []UserID{"1", "2", "3"}},
+		{"id", []UserID{"1", "2", "3"}},
+		{"-id", []UserID{"3", "2", "1"}},
+		{"name", []UserID{"2", "3", "1"}},
+		{"-name", []UserID{"1", "3", "2"}},
+		{"email", []UserID{"1", "3", "2"}},
+		{"-email", []UserID{"2", "3", "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("sort="+tt.sort, func(t *testing.T) {
+			rec := doRequest(h, http.MethodGet, "/users?sort="+tt.sort, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			var users []User
+			json.NewDecoder(rec.Body).Decode(&users)
+			if len(users) != len(tt.want) {
+				t.Fatalf("expected %d users, got %d", len(tt.want), len(users))
+			}
+			for i, id := range tt.want {
+				if users[i].ID != id {
+					t.Errorf("position %d: expected ID %s, got %s", i, id, users[i].ID)
+				}
+			}
+		})
+	}
+
+	if rec := doRequest(h, http.MethodGet, "/users?sort=age", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort key: expected 400, got %d", rec.Code)
+	}
+}
+
+// TestGetUser_ContentNegotiation tests the JSON and XML representations of a user
+func TestGetUser_ContentNegotiation(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+
+	tests := []struct {
+		accept      string
+		contentType string
+		want        string
+	}{
+		{"", "application/json", `"email":"john@example.com"`},
+		{"*/*", "application/json", `"email":"john@example.com"`},
+		{"application/xml", "application/xml", "<email>john@example.com</email>"},
+		{"application/json, application/xml", "application/json", `"email":"john@example.com"`},
+	}
+
+	for _, tt := range tests {
+		t.Run("accept="+tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			h.Router(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d", rec.Code)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("expected Content-Type %s, got %s", tt.contentType, got)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("body %q should contain %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+
+	// Errors follow the same negotiation
+	req := httptest.NewRequest(http.MethodGet, "/users/99", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	h.Router(rec, req)
+	if !strings.Contains(rec.Body.String(), "<error><error>not_found</error>") {
+		t.Errorf("expected XML error body, got %s", rec.Body.String())
+	}
+}
+
+// TestGetUser_ETag tests that a repeated GET with the ETag gets 304 until the user changes
+func TestGetUser_ETag(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`)
+
+	rec := doRequest(h, http.MethodGet, "/users/1", "")
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET user should set an ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	h.Router(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", rec.Code)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("304 must not have a body, got %q", rec.Body.String())
+	}
+
+	// After an update the old ETag no longer matches
+	doRequest(h, http.MethodPut, "/users/1", `{"name":"John Smith","email":"john@example.com"}`)
+	rec = httptest.NewRecorder()
+	h.Router(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after the user changed, got %d", rec.Code)
+	}
+}
+
+// TestValidateName_Unicode tests rune-based length, whitespace collapsing and control characters
+func TestValidateName_Unicode(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"emoji", "Ana 😀", false},
+		{"cjk", "山田太郎", false},
+		{"100 cjk runes", strings.Repeat("山", 100), false},
+		{"101 cjk runes", strings.Repeat("山", 101), true},
+		{"null bytes", "\u0000\u0000", true},
+		{"zero width", "\u200b\u200b\u200b", true},
+		{"control injection", "John\nDoe", true},
+		{"surrounding whitespace", "  John  ", false},
+		{"collapsed single rune", " J ", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidationConfig{}.validateName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := normalizeName("  John    Ronald   Doe "); got != "John Ronald Doe" {
+		t.Errorf("expected collapsed whitespace, got %q", got)
+	}
+}
+
+// TestCreateUser_Phone tests a valid E.164 number, a malformed one and the optional empty case
+func TestCreateUser_Phone(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+
+	tests := []struct {
+		name  string
+		body  string
+		code  int
+		phone string
+	}{
+		{"valid e164", `{"name":"John","email":"john@example.com","phone":"+6281234567890"}`, http.StatusCreated, "+6281234567890"},
+		{"malformed", `{"name":"Jane","email":"jane@example.com","phone":"0812-3456"}`, http.StatusBadRequest, ""},
+		{"too long", `{"name":"Jane","email":"jane@example.com","phone":"+1234567890123456"}`, http.StatusBadRequest, ""},
+		{"empty is optional", `{"name":"Jim","email":"jim@example.com"}`, http.StatusCreated, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, http.MethodPost, "/users", tt.body)
+			if rec.Code != tt.code {
+				t.Fatalf("expected %d, got %d: %s", tt.code, rec.Code, rec.Body.String())
+			}
+			if tt.code != http.StatusCreated {
+				return
+			}
+			var user User
+			json.NewDecoder(rec.Body).Decode(&user)
+			if user.Phone != tt.phone {
+				t.Errorf("expected phone %q, got %q", tt.phone, user.Phone)
+			}
+		})
+	}
+}
+
+// TestRouter_TrailingSlash tests that a trailing slash resolves to the same user
+func TestRouter_TrailingSlash(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	doRequest(h, http.MethodPost, "/users/", `{"name":"John","email":"john@example.com"}`)
+
+	for _, path := range []string{"/users/1", "/users/1/"} {
+		rec := doRequest(h, http.MethodGet, path, "")
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, rec.Code)
+		}
+	}
+}
+
+// TestRouter_NonPositiveID tests that zero, negative and out-of-range IDs are rejected with 400
+func TestRouter_NonPositiveID(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	body := `{"name":"John","email":"john@example.com"}`
+
+	for _, id := range []string{"0", "-1", "99999999999999999999999"} {
+		for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+			rec := doRequest(h, method, "/users/"+id, body)
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("%s /users/%s: expected 400, got %d", method, id, rec.Code)
+				continue
+			}
+			if msg := decodeError(t, rec).Message; msg != "Invalid user ID" {
+				t.Errorf("%s /users/%s: unexpected message %q", method, id, msg)
+			}
+		}
+	}
+}
+
+// TestCreateUser_CanonicalEmailDuplicate tests that emails differing only in case or surrounding
+// whitespace are duplicates on create and update
+func TestCreateUser_CanonicalEmailDuplicate(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+
+	if rec := doRequest(h, http.MethodPost, "/users", `{"name":"John Doe","email":"  John@X.com "}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"Johnny Doe","email":"john@x.com"}`)
+	if apiErr := decodeError(t, rec); rec.Code != http.StatusBadRequest || apiErr.Message != "email already exists" {
+		t.Errorf("expected the duplicate to be rejected, got %d %+v", rec.Code, apiErr)
+	}
+
+	doRequest(h, http.MethodPost, "/users", `{"name":"Jane Doe","email":"jane@example.com"}`)
+	rec = doRequest(h, http.MethodPut, "/users/2", `{"name":"Jane Doe","email":"JOHN@x.com"}`)
+	if apiErr := decodeError(t, rec); rec.Code != http.StatusBadRequest || apiErr.Message != "email already exists" {
+		t.Errorf("expected the update to be rejected, got %d %+v", rec.Code, apiErr)
+	}
+}
+
+// TestCreateUser_IDNEmailDuplicate tests that with AllowIDN the Unicode and punycode spellings of
+// a domain are one address, stored in punycode
+func TestCreateUser_IDNEmailDuplicate(t *testing.T) {
+	h := NewUserHandlerWithConfig(NewUserStore(), ValidationConfig{AllowIDN: true})
+
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"John Doe","email":"john@München.de"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var user User
+	json.NewDecoder(rec.Body).Decode(&user)
+	if user.Email != "john@xn--mnchen-3ya.de" {
+		t.Errorf("expected the punycode domain to be stored, got %q", user.Email)
+	}
+
+	rec = doRequest(h, http.MethodPost, "/users", `{"name":"Johnny Doe","email":"john@xn--mnchen-3ya.de"}`)
+	if apiErr := decodeError(t, rec); rec.Code != http.StatusBadRequest || apiErr.Message != "email already exists" {
+		t.Errorf("expected the punycode spelling to be a duplicate, got %d %+v", rec.Code, apiErr)
+	}
+	if rec := doRequest(h, http.MethodGet, "/users/email/john@münchen.de", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected the Unicode spelling to find the user, got %d", rec.Code)
+	}
+}
+
+// TestCreateUser tests the create endpoint responses
+func TestCreateUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		code    string
+		message string
+	}{
+		{"success", `{"name":"John Doe","email":"john@example.com"}`, http.StatusCreated, "", ""},
+		{"duplicate email", `{"name":"Jane Doe","email":"taken@example.com"}`, http.StatusBadRequest, "validation_error", "email already exists"},
+		{"empty body", ``, http.StatusBadRequest, "invalid_request", "Request body is required"},
+		{"truncated json", `{"name":`, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: body ends unexpectedly"},
+		{"malformed json", `{"name" "John"}`, http.StatusBadRequest, "invalid_request", "Invalid JSON payload at byte 9: invalid character '\"' after object key"},
+		{"wrong field type", `{"name":42,"email":"john@example.com"}`, http.StatusBadRequest, "invalid_request", "Invalid JSON payload: name must be a string, got number"},
+		{"bad email", `{"name":"John Doe","email":"not-an-email"}`, http.StatusBadRequest, "validation_error", "invalid email format"},
+		{"missing email", `{"name":"John Doe"}`, http.StatusBadRequest, "validation_error", "email is required"},
+		{"short name", `{"name":"J","email":"j@example.com"}`, http.StatusBadRequest, "validation_error", "name must be at least 2 characters long"},
+		{"missing name", `{"email":"noname@example.com"}`, http.StatusBadRequest, "validation_error", "name is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewUserStore()
+			store.Create("Taken", "taken@example.com", "")
+			h := NewUserHandler(store)
+
+			rec := doRequest(h, http.MethodPost, "/users", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+
+			if tt.code == "" {
+				var user User
+				if err := json.NewDecoder(rec.Body).Decode(&user); err != nil {
+					t.Fatalf("invalid user body: %v", err)
+				}
+				if user.ID != "2" || user.Name != "John Doe" || user.Email != "john@example.com" {
+					t.Errorf("unexpected user: %+v", user)
+				}
+				if location := rec.Header().Get("Location"); location != "/users/2" {
+					t.Errorf("expected Location /users/2, got %q", location)
+				}
+				return
+			}
+
+			apiErr := decodeError(t, rec)
+			if apiErr.Error != tt.code || apiErr.Message != tt.message {
+				t.Errorf("expected %s/%q, got %s/%q", tt.code, tt.message, apiErr.Error, apiErr.Message)
+			}
+		})
+	}
+}
+
+// TestGetUser tests fetching a user by ID
+func TestGetUser(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "")
+	h := NewUserHandler(store)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		code   string
+	}{
+		{"found", "/users/1", http.StatusOK, ""},
+		{"found with trailing slash", "/users/1/", http.StatusOK, ""},
+		{"not found", "/users/99", http.StatusNotFound, "not_found"},
+		{"bad id", "/users/abc", http.StatusBadRequest, "invalid_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, http.MethodGet, tt.path, "")
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+
+			if tt.code == "" {
+				var user User
+				json.NewDecoder(rec.Body).Decode(&user)
+				if user.ID != "1" || user.Email != "john@example.com" {
+					t.Errorf("unexpected user: %+v", user)
+				}
+				return
+			}
+			if apiErr := decodeError(t, rec); apiErr.Error != tt.code {
+				t.Errorf("expected error %s, got %s", tt.code, apiErr.Error)
+			}
+		})
+	}
+}
+
+// TestUpdateUser tests updating a user
+func TestUpdateUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"success", "/users/1", `{"name":"John Smith","email":"smith@example.com"}`, http.StatusOK, ""},
+		{"not found", "/users/99", `{"name":"John Smith","email":"smith@example.com"}`, http.StatusNotFound, "not_found"},
+		{"bad id", "/users/abc", `{"name":"John Smith","email":"smith@example.com"}`, http.StatusBadRequest, "invalid_request"},
+		{"invalid json", "/users/1", `{`, http.StatusBadRequest, "invalid_request"},
+		{"bad email", "/users/1", `{"name":"John Smith","email":"nope"}`, http.StatusBadRequest, "validation_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewUserStore()
+			store.Create("John Doe", "john@example.com", "")
+			h := NewUserHandler(store)
+
+			rec := doRequest(h, http.MethodPut, tt.path, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+
+			if tt.code == "" {
+				var user User
+				json.NewDecoder(rec.Body).Decode(&user)
+				if user.Name != "John Smith" || user.Email != "smith@example.com" || user.Version != 2 {
+					t.Errorf("unexpected user: %+v", user)
+				}
+				return
+			}
+			if apiErr := decodeError(t, rec); apiErr.Error != tt.code {
+				t.Errorf("expected error %s, got %s", tt.code, apiErr.Error)
+			}
+		})
+	}
+}
+
+// TestDeleteUser tests deleting a user
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"success", "/users/1", http.StatusOK},
+		{"not found", "/users/99", http.StatusNotFound},
+		{"bad id", "/users/abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewUserStore()
+			store.Create("John Doe", "john@example.com", "")
+			h := NewUserHandler(store)
+
+			rec := doRequest(h, http.MethodDelete, tt.path, "")
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var body map[string]string
+			json.NewDecoder(rec.Body).Decode(&body)
+			if body["message"] != "User deleted successfully" {
+				t.Errorf("unexpected body: %v", body)
+			}
+			if rec := doRequest(h, http.MethodGet, "/users/1", ""); rec.Code != http.StatusNotFound {
+				t.Errorf("deleted user should not be found, got %d", rec.Code)
+			}
+		})
+	}
+}
+
+// TestRouter_MethodNotAllowed tests unsupported methods on known routes
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	for path, method := range map[string]string{"/users": http.MethodPatch, "/users/1": http.MethodPost} {
+		rec := doRequest(h, method, path, "")
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: expected 405, got %d", method, path, rec.Code)
+		}
+	}
+}
+
+// TestCreateUser_CustomNameBounds tests configured name length bounds and their messages
+func TestCreateUser_CustomNameBounds(t *testing.T) {
+	h := NewUserHandlerWithConfig(NewUserStore(), ValidationConfig{MinNameLength: 1, MaxNameLength: 5})
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+	}{
+		{"single letter", `{"name":"J","email":"j@example.com"}`, http.StatusCreated, ""},
+		{"at max", `{"name":"Alice","email":"alice@example.com"}`, http.StatusCreated, ""},
+		{"over max", `{"name":"Alice B","email":"ab@example.com"}`, http.StatusBadRequest, "name must not exceed 5 characters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, http.MethodPost, "/users", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if tt.message != "" {
+				if msg := decodeError(t, rec).Message; msg != tt.message {
+					t.Errorf("expected %q, got %q", tt.message, msg)
+				}
+			}
+		})
+	}
+
+	strict := ValidationConfig{MinNameLength: 4}
+	if err := strict.validateName("Bob"); err == nil || err.Error() != "name must be at least 4 characters long" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestCreateUser_TruncateNames tests that over-long names are cut at a rune boundary and stored
+func TestCreateUser_TruncateNames(t *testing.T) {
+	h := NewUserHandlerWithConfig(NewUserStore(), ValidationConfig{MaxNameLength: 5, TruncateNames: true})
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"multibyte at the boundary", "Zoë Ünal", "Zoë Ü"},
+		{"trailing space after the cut", "Jöhn Doe", "Jöhn"},
+		{"cjk", "李小龍李小龍", "李小龍李小"},
+		{"one over the limit", "Amélie", "Améli"},
+		{"short", "Zoë", "Zoë"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"name":%q,"email":"user%d@example.com"}`, tt.in, i)
+			rec := doRequest(h, http.MethodPost, "/users", body)
+			if rec.Code != http.StatusCreated {
+				t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var user User
+			json.NewDecoder(rec.Body).Decode(&user)
+			if user.Name != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, user.Name)
+			}
+			if !utf8.ValidString(user.Name) {
+				t.Errorf("truncated name %q contains a broken rune", user.Name)
+			}
+		})
+	}
+
+	// The default still rejects
+	if err := (ValidationConfig{MaxNameLength: 5}).validateName("Zoë Ünal"); err == nil {
+		t.Error("expected an error without TruncateNames")
+	}
+}
+
+// TestUserStore_CreateLogsOnce tests that Create logs a single line regardless of how many users exist
+func TestUserStore_CreateLogsOnce(t *testing.T) {
+	store := NewUserStore()
+	for i := 0; i < 100; i++ {
+		store.Create("User", fmt.Sprintf("user%d@example.com", i), "")
+	}
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	store.Create("Last", "last@example.com", "")
+
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Errorf("expected 1 log line, got %d:\n%s", lines, buf.String())
+	}
+	if strings.Contains(buf.String(), "@example.com") {
+		t.Error("log line should not contain user emails")
+	}
+}
+
+// TestCreateUser_DisposableDomains tests rejecting caller-supplied disposable email domains
+func TestCreateUser_DisposableDomains(t *testing.T) {
+	h := NewUserHandlerWithConfig(NewUserStore(), ValidationConfig{
+		DisposableDomains: []string{"mailinator.com", "tempmail.dev"},
+	})
+
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"Spammer","email":"x@Mailinator.com"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("disposable domain: expected 400, got %d", rec.Code)
+	}
+	if msg := decodeError(t, rec).Message; msg != "disposable email addresses are not allowed" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	rec = doRequest(h, http.MethodPost, "/users", `{"name":"John","email":"john@gmail.com"}`)
+	if rec.Code != http.StatusCreated {
+		t.Errorf("normal domain: expected 201, got %d", rec.Code)
+	}
+}
+
+// TestValidateEmail_LengthAndIDN tests the RFC 5321 length limits and punycode handling of IDN domains
+func TestValidateEmail_LengthAndIDN(t *testing.T) {
+	// 64 + 1 + 185 + 4 = 254 bytes, right at the limit
+	longest := strings.Repeat("a", 64) + "@" + strings.Repeat("b", 185) + ".com"
+
+	tests := []struct {
+		name   string
+		config ValidationConfig
+		email  string
+		want   string
+	}{
+		{"plain ascii", ValidationConfig{}, "john@example.com", ""},
+		{"plain ascii with idn", ValidationConfig{AllowIDN: true}, "john@example.com", ""},
+		{"at the length limit", ValidationConfig{}, longest, ""},
+		{"over the length limit", ValidationConfig{}, "a" + longest[1:] + "m", "email must not exceed 254 characters"},
+		{"custom length limit", ValidationConfig{MaxEmailLength: 20}, "john.doe@example.com.au", "email must not exceed 20 characters"},
+		{"local part too long", ValidationConfig{}, strings.Repeat("a", 65) + "@example.com", "email local part must not exceed 64 characters"},
+		{"idn domain rejected by default", ValidationConfig{}, "user@münchen.de", "invalid email format"},
+		{"idn domain", ValidationConfig{AllowIDN: true}, "user@münchen.de", ""},
+		{"idn domain on the block list", ValidationConfig{AllowIDN: true, BlockedDomains: []string{"xn--mnchen-3ya.de"}}, "user@münchen.de", "email domain not allowed"},
+		{"invalid idn domain", ValidationConfig{AllowIDN: true}, "user@-münchen.de", "invalid email domain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.validateEmail(tt.email)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("expected %q to be valid, got %v", tt.email, err)
+			case tt.want != "" && (err == nil || err.Error() != tt.want):
+				t.Errorf("expected error %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestBatchGetUsers tests fetching a mix of present and absent IDs in one request
+func TestBatchGetUsers(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "")
+	store.Create("Jane Doe", "jane@example.com", "")
+	h := NewUserHandler(store)
+
+	rec := doRequest(h, http.MethodPost, "/users/batch-get", `{"ids":[2,5,1]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp BatchGetResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if len(resp.Users) != 2 || resp.Users[0].ID != "2" || resp.Users[1].ID != "1" {
+		t.Errorf("unexpected users: %+v", resp.Users)
+	}
+	if len(resp.Missing) != 1 || resp.Missing[0] != "5" {
+		t.Errorf("expected missing [5], got %v", resp.Missing)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		body   string
+		status int
+	}{
+		{"empty ids", http.MethodPost, `{"ids":[]}`, http.StatusBadRequest},
+		{"invalid json", http.MethodPost, `{"ids":`, http.StatusBadRequest},
+		{"too many ids", http.MethodPost, `{"ids":[` + strings.Repeat("1,", maxBatchGetIDs) + `1]}`, http.StatusBadRequest},
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := doRequest(h, tt.method, "/users/batch-get", tt.body); rec.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rec.Code)
+			}
+		})
+	}
+}
+
+// TestHeadUser tests existence checks return only a status and headers
+func TestHeadUser(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "")
+	h := NewUserHandler(store)
+
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{"exists", "/users/1", http.StatusOK},
+		{"missing", "/users/99", http.StatusNotFound},
+		{"bad id", "/users/abc", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, http.MethodHead, tt.path, "")
+			if rec.Code != tt.status {
+				t.Errorf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if rec.Body.Len() != 0 {
+				t.Errorf("expected an empty body, got %q", rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("unexpected Content-Type %q", ct)
+			}
+		})
+	}
+}
+
+// TestUserStore_Limit tests that exactly maxUsers concurrent creates succeed and the rest get ErrStoreFull
+func TestUserStore_Limit(t *testing.T) {
+	const maxUsers = 10
+	store := NewUserStoreWithLimit(maxUsers)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created, full := 0, 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.Create("User", fmt.Sprintf("user%d@example.com", i), "")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				created++
+			case errors.Is(err, ErrStoreFull):
+				full++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if created != maxUsers || full != 40 {
+		t.Errorf("expected %d created and 40 rejected, got %d and %d", maxUsers, created, full)
+	}
+
+	// The handler maps the limit to 403
+	h := NewUserHandler(store)
+	rec := doRequest(h, http.MethodPost, "/users", `{"name":"Late","email":"late@example.com"}`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+	if msg := decodeError(t, rec).Message; msg != "user limit reached" {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+// TestGetOrCreateUser tests the 201 then 200 responses for the same email
+func TestGetOrCreateUser(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+
+	rec := doRequest(h, http.MethodPost, "/users/get-or-create", `{"name":"John Doe","email":"john@example.com"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("first call: expected 201, got %d", rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != "/users/1" {
+		t.Errorf("expected Location /users/1, got %q", location)
+	}
+
+	rec = doRequest(h, http.MethodPost, "/users/get-or-create", `{"name":"Other Name","email":"john@example.com"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second call: expected 200, got %d", rec.Code)
+	}
+	if location := rec.Header().Get("Location"); location != "" {
+		t.Errorf("an existing user should not get a Location header, got %q", location)
+	}
+	var user User
+	json.NewDecoder(rec.Body).Decode(&user)
+	if user.ID != "1" || user.Name != "John Doe" {
+		t.Errorf("expected the existing user, got %+v", user)
+	}
+
+	rec = doRequest(h, http.MethodPost, "/users/get-or-create", `{"name":"John Doe","email":"bad"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid email: expected 400, got %d", rec.Code)
+	}
+}
+
+// TestValidateUser tests dry-run validation reports field errors and never creates a user
+func TestValidateUser(t *testing.T) {
+	store := NewUserStore()
+	store.Create("Taken", "taken@example.com", "")
+	h := NewUserHandler(store)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		errors map[string]string
+	}{
+		{"valid", `{"name":"John Doe","email":"john@example.com"}`, http.StatusOK, nil},
+		{"duplicate email", `{"name":"John Doe","email":"taken@example.com"}`, http.StatusBadRequest,
+			map[string]string{"email": "email already exists"}},
+		{"several fields", `{"name":"J","email":"nope","phone":"123"}`, http.StatusBadRequest,
+			map[string]string{
+				"name":  "name must be at least 2 characters long",
+				"email": "invalid email format",
+				"phone": "phone must be in E.164 format, e.g. +6281234567890",
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, http.MethodPost, "/users/validate", tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+			var result ValidationResult
+			if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+				t.Fatalf("invalid body: %v", err)
+			}
+			if result.Valid != (tt.errors == nil) {
+				t.Errorf("unexpected valid flag: %+v", result)
+			}
+			for field, msg := range tt.errors {
+				if result.Errors[field] != msg {
+					t.Errorf("%s: expected %q, got %q", field, msg, result.Errors[field])
+				}
+			}
+		})
+	}
+
+	if store.Count() != 1 {
+		t.Errorf("validation must not create users, count is %d", store.Count())
+	}
+}
+
+// TestUpdateUserEmail tests PATCH /users/:id/email
+func TestUpdateUserEmail(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John", "john@example.com", "")
+	store.Create("Jane", "jane@example.com", "")
+	store.users["1"].EmailVerified = true
+	h := NewUserHandler(store)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"success", http.MethodPatch, "/users/1/email", `{"email":"john.new@example.com"}`, http.StatusOK},
+		{"duplicate", http.MethodPatch, "/users/1/email", `{"email":"jane@example.com"}`, http.StatusBadRequest},
+		{"invalid email", http.MethodPatch, "/users/1/email", `{"email":"nope"}`, http.StatusBadRequest},
+		{"missing user", http.MethodPatch, "/users/99/email", `{"email":"x@example.com"}`, http.StatusNotFound},
+		{"invalid id", http.MethodPatch, "/users/abc/email", `{"email":"x@example.com"}`, http.StatusBadRequest},
+		{"wrong method", http.MethodPut, "/users/1/email", `{"email":"x@example.com"}`, http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequest(h, tt.method, tt.path, tt.body)
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			var user User
+			json.NewDecoder(rec.Body).Decode(&user)
+			if user.Email != "john.new@example.com" || user.EmailVerified {
+				t.Errorf("expected the new email unverified, got %+v", user)
+			}
+		})
+	}
+}
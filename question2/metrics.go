@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// requestKey identifies a request counter by method and response status
+type requestKey struct {
+	method string
+	status int
+}
+
+// Metrics collects counters exposed in the Prometheus text format on /metrics.
+// All methods are safe to call on a nil *Metrics, which records nothing
+type Metrics struct {
+	requests    sync.Map // requestKey -> *atomic.Int64
+	cacheHits   atomic.Int64
+	cacheMisses atomic.Int64
+}
+
+// NewMetrics creates a new Metrics instance
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// RecordRequest counts one request with the given method and response status.
+// Methods outside the standard set are counted as OTHER, so clients can't create series at will
+func (m *Metrics) RecordRequest(method string, status int) {
+	if m == nil {
+		return
+	}
+	counter, _ := m.requests.LoadOrStore(requestKey{method: metricMethod(method), status: status}, new(atomic.Int64))
+	counter.(*atomic.Int64).Add(1)
+}
+
+// metricMethod returns method if it is one of the standard HTTP methods, otherwise "OTHER"
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+// RecordCacheHit counts a cache lookup that found a value
+func (m *Metrics) RecordCacheHit() {
+	if m != nil {
+		m.cacheHits.Add(1)
+	}
+}
+
+// RecordCacheMiss counts a cache lookup that found nothing
+func (m *Metrics) RecordCacheMiss() {
+	if m != nil {
+		m.cacheMisses.Add(1)
+	}
+}
+
+// Handler serves GET /metrics in the Prometheus text exposition format
+func (m *Metrics) Handler(store Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+			return
+		}
+
+		// sort the request counters so the output is stable between scrapes
+		var keys []requestKey
+		m.requests.Range(func(key, _ interface{}) bool {
+			keys = append(keys, key.(requestKey))
+			return true
+		})
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].method != keys[j].method {
+				return keys[i].method < keys[j].method
+			}
+			return keys[i].status < keys[j].status
+		})
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintln(w, "# HELP http_requests_total Total HTTP requests by method and status.")
+		fmt.Fprintln(w, "# TYPE http_requests_total counter")
+		for _, key := range keys {
+			counter, _ := m.requests.Load(key)
+			fmt.Fprintf(w, "http_requests_total{method=%q,status=\"%d\"} %d\n", key.method, key.status, counter.(*atomic.Int64).Load())
+		}
+
+		fmt.Fprintln(w, "# HELP users_total Current number of active users.")
+		fmt.Fprintln(w, "# TYPE users_total gauge")
+		fmt.Fprintf(w, "users_total %d\n", store.Count())
+
+		fmt.Fprintln(w, "# HELP cache_hits_total Cache lookups that found a value.")
+		fmt.Fprintln(w, "# TYPE cache_hits_total counter")
+		fmt.Fprintf(w, "cache_hits_total %d\n", m.cacheHits.Load())
+		fmt.Fprintln(w, "# HELP cache_misses_total Cache lookups that found nothing.")
+		fmt.Fprintln(w, "# TYPE cache_misses_total counter")
+		fmt.Fprintf(w, "cache_misses_total %d\n", m.cacheMisses.Load())
+	}
+}
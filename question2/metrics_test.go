@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"question3/cache"
+)
+
+// TestMetricsEndpoint tests request, user and cache counters in the exposition output
+func TestMetricsEndpoint(t *testing.T) {
+	store := NewUserStore()
+	ttlCache := cache.NewTTLCache(time.Minute)
+	defer ttlCache.Stop()
+	server := NewServer(store, ttlCache, ServerConfig{})
+
+	requests := []struct{ method, path, body string }{
+		{http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`},
+		{http.MethodGet, "/users/1", ""},
+		{http.MethodGet, "/users/99", ""},
+		{http.MethodPut, "/cache/greeting", `{"value":"hi"}`},
+		{http.MethodGet, "/cache/greeting", ""},
+		{http.MethodGet, "/cache/missing", ""},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+		req.Header.Set("Content-Type", "application/json")
+		server.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="POST",status="201"} 1`,
+		`http_requests_total{method="GET",status="200"} 2`,
+		`http_requests_total{method="GET",status="404"} 2`,
+		"users_total 1",
+		"cache_hits_total 1",
+		"cache_misses_total 1",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics output missing %q:\n%s", want, body)
+		}
+	}
+}
+
+// TestMetrics_UnknownMethods tests that arbitrary method tokens share one OTHER series
+func TestMetrics_UnknownMethods(t *testing.T) {
+	metrics := NewMetrics()
+	for i := 0; i < 100; i++ {
+		metrics.RecordRequest(fmt.Sprintf("X-CUSTOM-%d", i), http.StatusMethodNotAllowed)
+	}
+	metrics.RecordRequest(http.MethodGet, http.StatusOK)
+
+	series := 0
+	metrics.requests.Range(func(key, value interface{}) bool {
+		series++
+		return true
+	})
+	if series != 2 {
+		t.Errorf("expected 2 series, got %d", series)
+	}
+
+	rec := httptest.NewRecorder()
+	metrics.Handler(NewUserStore())(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if want := `http_requests_total{method="OTHER",status="405"} 100`; !strings.Contains(rec.Body.String(), want) {
+		t.Errorf("metrics output missing %q:\n%s", want, rec.Body.String())
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestRequestIDMiddleware tests echoing a client ID and generating one when it is omitted
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	// Client supplied ID is echoed and available to handlers
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Request-ID", "trace-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "trace-123" {
+		t.Errorf("expected echoed trace-123, got %q", got)
+	}
+	if seen != "trace-123" {
+		t.Errorf("handler should see trace-123 in context, got %q", seen)
+	}
+
+	// Missing ID gets a generated UUID
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if got := rec.Header().Get("X-Request-ID"); !uuid.MatchString(got) {
+		t.Errorf("expected a generated UUID, got %q", got)
+	}
+	if seen != rec.Header().Get("X-Request-ID") {
+		t.Errorf("context ID %q should match the response header", seen)
+	}
+}
+
+// TestCORSMiddleware tests an allowed origin, a disallowed one and a preflight request
+func TestCORSMiddleware(t *testing.T) {
+	reached := false
+	handler := CORSMiddleware([]string{"https://app.example.com"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	// Allowed origin is echoed
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("allowed origin: expected it to be echoed, got %q", got)
+	}
+
+	// Disallowed origin gets no CORS headers but still reaches the handler
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("disallowed origin: expected no Allow-Origin, got %q", got)
+	}
+
+	// Preflight is short-circuited with 204
+	reached = false
+	req = httptest.NewRequest(http.MethodOptions, "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight: expected 204, got %d", rec.Code)
+	}
+	if reached {
+		t.Error("preflight should not reach the handler")
+	}
+	if rec.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Error("preflight should list the allowed methods")
+	}
+	allowed := rec.Header().Get("Access-Control-Allow-Headers")
+	for _, name := range []string{"Idempotency-Key", "If-Match", "If-None-Match"} {
+		if !strings.Contains(allowed, name) {
+			t.Errorf("preflight should allow the %s header, got %q", name, allowed)
+		}
+	}
+
+	// Wildcard allows any origin
+	wildcard := CORSMiddleware([]string{"*"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("Origin", "https://anything.example.com")
+	rec = httptest.NewRecorder()
+	wildcard.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("wildcard: expected *, got %q", got)
+	}
+}
+
+// TestRequireJSONMiddleware tests rejecting non-JSON write bodies and exempting body-less requests
+func TestRequireJSONMiddleware(t *testing.T) {
+	handler := RequireJSONMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		body        string
+		contentType string
+		status      int
+	}{
+		{"text/plain post", http.MethodPost, "name=John", "text/plain", http.StatusUnsupportedMediaType},
+		{"form post", http.MethodPut, "name=John", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"missing content type", http.MethodPatch, `{}`, "", http.StatusUnsupportedMediaType},
+		{"json post", http.MethodPost, `{"name":"John"}`, "application/json", http.StatusOK},
+		{"json with charset", http.MethodPost, `{}`, "application/json; charset=utf-8", http.StatusOK},
+		{"get without body", http.MethodGet, "", "", http.StatusOK},
+		{"delete without body", http.MethodDelete, "", "", http.StatusOK},
+		{"post without body", http.MethodPost, "", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/users", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d", tt.status, rec.Code)
+			}
+			if tt.status == http.StatusUnsupportedMediaType {
+				if code := decodeError(t, rec).Error; code != "unsupported_media_type" {
+					t.Errorf("unexpected error code %s", code)
+				}
+			}
+		})
+	}
+}
+
+// TestChain tests that the first middleware is the outermost
+func TestChain(t *testing.T) {
+	var order []string
+	trace := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name+" before")
+				next.ServeHTTP(w, r)
+				order = append(order, name+" after")
+			})
+		}
+	}
+	handler := Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), trace("outer"), trace("inner"))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := "outer before,inner before,handler,inner after,outer after"
+	if got := strings.Join(order, ","); got != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// mergePatchFields are the user fields PATCH /users/:id accepts, in the order they are validated
+var mergePatchFields = []string{"name", "email", "phone"}
+
+// PatchUser handles PATCH /users/:id with RFC 7386 JSON Merge Patch semantics: fields present in
+// the body are replaced, absent fields are left untouched and null clears a field. Only the
+// optional phone can be cleared. The update is applied against the version that was read,
+// so a concurrent change answers 409 instead of being overwritten
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only PATCH method is allowed")
+		return
+	}
+
+	pathParts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(pathParts) != 2 {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid URL format")
+		return
+	}
+	id, err := parseUserID(pathParts[1])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Invalid user ID")
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	err = json.NewDecoder(r.Body).Decode(&patch)
+	var typeErr *json.UnmarshalTypeError
+	if err != nil && !errors.As(err, &typeErr) {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", decodeErrorMessage(err))
+		return
+	}
+	if err != nil || patch == nil {
+		respondWithError(w, r, http.StatusBadRequest, "invalid_request", "Body must be a JSON merge patch object")
+		return
+	}
+	if unknown := unknownPatchFields(patch); len(unknown) > 0 {
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", fmt.Sprintf("fields cannot be patched: %s", strings.Join(unknown, ", ")))
+		return
+	}
+
+	current, exists := h.store.Get(id)
+	if !exists {
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
+		return
+	}
+
+	values := map[string]string{"name": current.Name, "email": current.Email, "phone": current.Phone}
+	for _, field := range mergePatchFields {
+		raw, present := patch[field]
+		if !present {
+			continue
+		}
+		value, err := h.applyPatchField(field, raw)
+		if err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		values[field] = value
+	}
+
+	user, err := h.store.UpdateIfVersion(id, values["name"], values["email"], values["phone"], current.Version)
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		respondWithError(w, r, http.StatusNotFound, "not_found", "User not found")
+	case errors.Is(err, ErrVersionConflict):
+		respondWithError(w, r, http.StatusConflict, "version_conflict", "User was modified by another request, fetch it and retry")
+	case errors.Is(err, ErrEmailExists):
+		respondWithError(w, r, http.StatusBadRequest, "validation_error", err.Error())
+	case errors.Is(err, ErrStoreClosed):
+		respondWithError(w, r, http.StatusServiceUnavailable, "unavailable", "Server is shutting down")
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "internal_error", "Failed to update user")
+	default:
+		respondWith(w, r, http.StatusOK, user)
+	}
+}
+
+// applyPatchField validates one merge patch member and returns the value to store
+func (h *UserHandler) applyPatchField(field string, raw json.RawMessage) (string, error) {
+	if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		if field != "phone" {
+			return "", fmt.Errorf("%s is required and cannot be cleared", field)
+		}
+		return "", nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("%s must be a string", field)
+	}
+
+	switch field {
+	case "name":
+		if err := h.validation.validateName(value); err != nil {
+			return "", err
+		}
+		return h.validation.storedName(value), nil
+	case "email":
+		if err := h.validation.validateEmail(value); err != nil {
+			return "", err
+		}
+		return h.validation.storedEmail(value), nil
+	case "phone":
+		if err := validatePhone(value); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(value), nil
+}
+
+// unknownPatchFields returns the sorted members of patch that aren't patchable user fields
+func unknownPatchFields(patch map[string]json.RawMessage) []string {
+	var unknown []string
+	for field := range patch {
+		known := false
+		for _, f := range mergePatchFields {
+			known = known || f == field
+		}
+		if !known {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
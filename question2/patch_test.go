@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestPatchUser tests JSON Merge Patch semantics for set, clear and untouched fields
+func TestPatchUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		want    User
+		message string
+	}{
+		{"set name, others untouched", `{"name":"John Smith"}`, http.StatusOK,
+			User{Name: "John Smith", Email: "john@example.com", Phone: "+6281234567890", Version: 2}, ""},
+		{"set email and phone", `{"email":"js@example.com","phone":"+6289876543210"}`, http.StatusOK,
+			User{Name: "John", Email: "js@example.com", Phone: "+6289876543210", Version: 2}, ""},
+		{"clear phone", `{"phone":null}`, http.StatusOK,
+			User{Name: "John", Email: "john@example.com", Phone: "", Version: 2}, ""},
+		{"empty patch", `{}`, http.StatusOK,
+			User{Name: "John", Email: "john@example.com", Phone: "+6281234567890", Version: 2}, ""},
+		{"clear email", `{"email":null}`, http.StatusBadRequest, User{}, "email is required and cannot be cleared"},
+		{"clear name", `{"name":null}`, http.StatusBadRequest, User{}, "name is required and cannot be cleared"},
+		{"invalid email", `{"email":"nope"}`, http.StatusBadRequest, User{}, "invalid email format"},
+		{"wrong type", `{"name":42}`, http.StatusBadRequest, User{}, "name must be a string"},
+		{"read-only fields", `{"version":9,"id":3}`, http.StatusBadRequest, User{}, "fields cannot be patched: id, version"},
+		{"not an object", `["name"]`, http.StatusBadRequest, User{}, "Body must be a JSON merge patch object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewUserStore()
+			store.Create("John", "john@example.com", "+6281234567890")
+			store.Create("Jane", "jane@example.com", "")
+			h := NewUserHandler(store)
+
+			req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			rec := httptest.NewRecorder()
+			h.Router(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if tt.message != "" {
+				if msg := decodeError(t, rec).Message; msg != tt.message {
+					t.Errorf("expected %q, got %q", tt.message, msg)
+				}
+				return
+			}
+
+			var user User
+			json.NewDecoder(rec.Body).Decode(&user)
+			if user.Name != tt.want.Name || user.Email != tt.want.Email || user.Phone != tt.want.Phone || user.Version != tt.want.Version {
+				t.Errorf("expected %+v, got %+v", tt.want, user)
+			}
+		})
+	}
+}
+
+// TestPatchUser_NotFound tests patching a missing user
+func TestPatchUser_NotFound(t *testing.T) {
+	h := NewUserHandler(NewUserStore())
+	if rec := doRequest(h, http.MethodPatch, "/users/9", `{"name":"Nobody"}`); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
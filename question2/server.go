@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+
+	"question3/cache"
+)
+
+// ServerConfig holds the options for NewServer
+type ServerConfig struct {
+	// AllowedOrigins lists the browser origins allowed by CORS, "*" allows any origin
+	AllowedOrigins []string
+	// Validation holds the rules user names and emails are checked against, the zero value
+	// applies the defaults
+	Validation ValidationConfig
+	// BulkConcurrency caps how many items of POST /users/bulk are processed at once, 0 uses the default
+	BulkConcurrency int
+	// IdempotencyCache stores POST /users responses by Idempotency-Key, nil disables the header.
+	// Keep it separate from the shared cache so /cache/ can't read or forge them
+	IdempotencyCache *cache.TTLCache
+	// CompressionMinSize is the smallest response body gzipped for clients accepting it,
+	// 0 uses defaultCompressionMinSize
+	CompressionMinSize int
+	// AllowAdminReset registers POST /admin/reset and POST /admin/seed for integration tests.
+	// Off by default, never enable it in production
+	AllowAdminReset bool
+}
+
+// NewServer wires the user API, the cache API, /metrics and /openapi.json into one handler,
+// plus the admin endpoints when cfg.AllowAdminReset is set
+func NewServer(store Store, ttlCache *cache.TTLCache, cfg ServerConfig) http.Handler {
+	metrics := NewMetrics()
+	userHandler := NewUserHandlerWithConfig(store, cfg.Validation)
+	userHandler.bulkConcurrency = cfg.BulkConcurrency
+	userHandler.idempotency = cfg.IdempotencyCache
+	cacheHandler := NewCacheHandler(ttlCache, metrics)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", userHandler.Router)
+	mux.HandleFunc("/cache/", cacheHandler.Router)
+	mux.Handle("/metrics", metrics.Handler(store))
+	mux.HandleFunc("/openapi.json", SpecHandler)
+	if cfg.AllowAdminReset {
+		mux.HandleFunc("/admin/reset", userHandler.ResetStore)
+		mux.HandleFunc("/admin/seed", userHandler.SeedUsers)
+	}
+
+	// CleanPathMiddleware is outermost so the mux never redirects and logs show the cleaned path,
+	// RequestIDMiddleware comes next so the logging middleware can include the ID,
+	// CORS runs inside logging so preflight requests are counted too, and before the
+	// Content-Type check so rejected responses still carry the CORS headers.
+	// Compression sits inside logging, which then records the status the handler chose
+	return Chain(mux,
+		CleanPathMiddleware,
+		RequestIDMiddleware,
+		LoggingMiddleware(metrics),
+		GzipMiddleware(cfg.CompressionMinSize),
+		CORSMiddleware(cfg.AllowedOrigins),
+		RequireJSONMiddleware,
+	)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"question3/cache"
+)
+
+// newTestServer creates a server over an in-memory store with the given config
+func newTestServer(t *testing.T, cfg ServerConfig) http.Handler {
+	t.Helper()
+	return NewServer(NewUserStore(), newTestCache(t), cfg)
+}
+
+// newTestCache creates a cache that is stopped with the test
+func newTestCache(t *testing.T) *cache.TTLCache {
+	t.Helper()
+	c := cache.NewTTLCache(time.Minute)
+	t.Cleanup(c.Stop)
+	return c
+}
+
+// TestNewServer_Validation tests that the configured validation rules reach the user handler
+func TestNewServer_Validation(t *testing.T) {
+	server := newTestServer(t, ServerConfig{Validation: ValidationConfig{BlockedDomains: []string{"blocked.com"}}})
+
+	if rec := doRequest(server, http.MethodPost, "/users", `{"name":"John","email":"john@blocked.com"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a blocked domain, got %d", rec.Code)
+	}
+	if rec := doRequest(server, http.MethodPost, "/users", `{"name":"John","email":"john@example.com"}`); rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestNewServer_PathCleaning tests that duplicate slashes and trailing slashes reach the handlers
+// cleaned instead of being redirected by the mux, so a POST isn't turned into a GET
+func TestNewServer_PathCleaning(t *testing.T) {
+	server := newTestServer(t, ServerConfig{})
+
+	if rec := doRequest(server, http.MethodPost, "//users//", `{"name":"John","email":"john@example.com"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("POST //users//: expected 201, got %d", rec.Code)
+	}
+	for _, path := range []string{"/users/1", "/users/1/", "//users//1", "/users//1//", "/users/./1", "/cache/../users/1"} {
+		if rec := doRequest(server, http.MethodGet, path, ""); rec.Code != http.StatusOK {
+			t.Errorf("GET %s: expected 200, got %d", path, rec.Code)
+		}
+	}
+	if rec := doRequest(server, http.MethodGet, "//cache//", ""); rec.Code == http.StatusMovedPermanently {
+		t.Errorf("GET //cache//: expected no redirect, got %d to %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
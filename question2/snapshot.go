@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// storeSnapshot is the JSON document written by Snapshot and read by RestoreSnapshot
+type storeSnapshot struct {
+	// NextID is the ID the next created user gets, 0 when the ID generator can't report it
+	NextID int     `json:"next_id"`
+	Users  []*User `json:"users"`
+}
+
+// Snapshot returns a JSON copy of every user, soft-deleted ones included, and the next ID,
+// taken under the read lock so it is consistent while writes are held off only briefly.
+// The users are ordered by ID, the array has the format of USERS_FILE
+func (s *UserStore) Snapshot() []byte {
+	s.mu.RLock()
+	snapshot := storeSnapshot{Users: make([]*User, 0, len(s.users))}
+	for _, user := range s.users {
+		snapshot.Users = append(snapshot.Users, user.clone())
+	}
+	if rewinder, ok := s.ids.(idRewinder); ok {
+		snapshot.NextID = rewinder.mark() + 1
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(snapshot.Users, func(i, j int) bool { return snapshot.Users[i].ID.less(snapshot.Users[j].ID) })
+	data, _ := json.Marshal(snapshot) // users only hold JSON-safe fields
+	return data
+}
+
+// RestoreSnapshot replaces the store contents with a snapshot taken by Snapshot under one write
+// lock, so readers see either the old or the restored users. The snapshot is rejected as a whole
+// if an ID is not positive or repeated or an email is used twice. New users get IDs from the
+// snapshot's next ID, or after the highest restored ID when that is larger. Subscribers get a
+// delete for every replaced user and a create for every restored one
+func (s *UserStore) RestoreSnapshot(data []byte) error {
+	var snapshot storeSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+	restored, maxID, err := checkUsers(snapshot.Users, nil)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %w", err)
+	}
+
+	for _, user := range sortedUsers(s.users) {
+		s.publish(ChangeDeleted, user)
+	}
+	s.users = restored
+	for _, user := range sortedUsers(s.users) {
+		s.publish(ChangeCreated, user)
+	}
+	if rewinder, ok := s.ids.(idRewinder); ok {
+		rewinder.rewind(max(snapshot.NextID-1, maxID))
+	}
+	return nil
+}
+
+// sortedUsers returns the users of the map ordered by ID
+func sortedUsers(users map[UserID]*User) []*User {
+	sorted := make([]*User, 0, len(users))
+	for _, user := range users {
+		sorted = append(sorted, user)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID.less(sorted[j].ID) })
+	return sorted
+}
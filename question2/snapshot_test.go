@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestUserStore_SnapshotRoundTrip tests that restoring a snapshot brings back the users, their
+// soft-delete state and the next ID after later creates, updates and deletes
+func TestUserStore_SnapshotRoundTrip(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "")
+	store.Create("Jane Doe", "jane@example.com", "+6281234567890")
+	store.Create("Bob", "bob@example.com", "")
+	store.Delete("3") // IDs are not reused, the next one stays 4
+	store.SoftDelete("2")
+
+	data := store.Snapshot()
+
+	store.Update("1", "Johnny", "johnny@example.com", "")
+	store.Restore("2")
+	store.Create("Alice", "alice@example.com", "")
+
+	if err := store.RestoreSnapshot(data); err != nil {
+		t.Fatalf("RestoreSnapshot failed: %v", err)
+	}
+	users := store.List(true)
+	if len(users) != 2 {
+		t.Fatalf("expected the 2 snapshotted users, got %d", len(users))
+	}
+	if users[0].Name != "John Doe" || users[0].Email != "john@example.com" || users[0].Version != 1 {
+		t.Errorf("expected user 1 as snapshotted, got %+v", users[0])
+	}
+	if users[1].DeletedAt == nil || users[1].Phone != "+6281234567890" {
+		t.Errorf("expected user 2 soft-deleted with its phone, got %+v", users[1])
+	}
+
+	created, err := store.Create("Alice", "alice@example.com", "")
+	if err != nil || created.ID != "4" {
+		t.Errorf("expected the next ID from the snapshot (4), got %+v (err %v)", created, err)
+	}
+}
+
+// TestUserStore_RestoreSnapshotInvalid tests that a snapshot breaking the invariants leaves the store untouched
+func TestUserStore_RestoreSnapshotInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"duplicate id", `{"users":[{"id":1,"email":"a@example.com"},{"id":1,"email":"b@example.com"}]}`, "duplicate id 1"},
+		{"duplicate email", `{"users":[{"id":1,"email":"a@example.com"},{"id":2,"email":"a@example.com"}]}`, "duplicate email"},
+		{"invalid id", `{"users":[{"id":-1,"email":"a@example.com"}]}`, "invalid id -1"},
+		{"not json", `[`, "decode snapshot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewUserStore()
+			store.Create("John Doe", "john@example.com", "")
+
+			err := store.RestoreSnapshot([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+			if _, exists := store.Get("1"); !exists || store.Count() != 1 {
+				t.Error("a rejected snapshot must not change the store")
+			}
+		})
+	}
+
+	store := NewUserStore()
+	store.Close()
+	if err := store.RestoreSnapshot(NewUserStore().Snapshot()); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("expected ErrStoreClosed, got %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// OpenAPI is the subset of an OpenAPI 3.0 document the user API needs
+type OpenAPI struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       OpenAPIInfo         `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps lowercase HTTP methods to operations
+type PathItem map[string]Operation
+
+// Operation describes one endpoint
+type Operation struct {
+	Summary     string              `json:"summary"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response for one status code
+type Response struct {
+	Description string               `json:"description"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Header is a response header
+type Header struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the reusable schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Schema is a JSON schema, either inline or a $ref to a component
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	OneOf      []*Schema          `json:"oneOf,omitempty"`
+}
+
+// userIDSchema describes a UserID: a number from the sequential generator or a UUID
+func userIDSchema() *Schema {
+	return &Schema{OneOf: []*Schema{{Type: "integer"}, {Type: "string", Format: "uuid"}}}
+}
+
+// schemaFor derives a schema from a Go type using its json tags, so the spec follows the structs.
+// Fields listed in required are marked as required
+func schemaFor(t reflect.Type, required ...string) *Schema {
+	if t.Kind() == reflect.Pointer {
+		schema := schemaFor(t.Elem())
+		schema.Nullable = true
+		return schema
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t == reflect.TypeOf(UserID("")) {
+		return userIDSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: schemaFor(t.Elem())}
+	case reflect.Struct:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}, Required: required}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			schema.Properties[name] = schemaFor(field.Type)
+		}
+		return schema
+	}
+	return &Schema{}
+}
+
+// ref returns a schema referencing a component
+func ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// jsonContent wraps a schema as an application/json body
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// errorResponse is a response carrying an APIError
+func errorResponse(description string) Response {
+	return Response{Description: description, Content: jsonContent(ref("APIError"))}
+}
+
+// userIDParam is the {id} path parameter
+var userIDParam = Parameter{Name: "id", In: "path", Required: true, Schema: userIDSchema()}
+
+var (
+	// fieldsParam selects user fields, e.g. ?fields=id,name
+	fieldsParam = Parameter{Name: "fields", In: "query", Schema: &Schema{Type: "string"}}
+	// prettyParam indents JSON responses
+	prettyParam = Parameter{Name: "pretty", In: "query", Schema: &Schema{Type: "boolean"}}
+)
+
+// apiSpec builds the OpenAPI document for the user API, update it alongside the handlers
+func apiSpec() OpenAPI {
+	userResponse := func(description string) Response {
+		return Response{Description: description, Content: jsonContent(ref("User"))}
+	}
+	createdResponse := userResponse("Created user")
+	createdResponse.Headers = map[string]Header{
+		"Location": {Description: "Path of the created user, /users/{id}", Schema: &Schema{Type: "string"}},
+	}
+
+	return OpenAPI{
+		OpenAPI: "3.0.3",
+		Info:    OpenAPIInfo{Title: "User API", Version: "1.0.0"},
+		Paths: map[string]PathItem{
+			"/users": {
+				"get": {
+					Summary: "List users",
+					Parameters: []Parameter{
+						{Name: "sort", In: "query", Schema: &Schema{Type: "string"}},
+						{Name: "include_deleted", In: "query", Schema: &Schema{Type: "boolean"}},
+						fieldsParam,
+						prettyParam,
+					},
+					Responses: map[string]Response{
+						"200": {Description: "Users", Content: jsonContent(&Schema{Type: "array", Items: ref("User")})},
+						"400": errorResponse("Invalid sort key or field"),
+					},
+				},
+				"post": {
+					Summary: "Create a user, repeats with the same Idempotency-Key replay the first 201",
+					Parameters: []Parameter{
+						{Name: "Idempotency-Key", In: "header", Schema: &Schema{Type: "string"}},
+					},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("CreateUserRequest"))},
+					Responses: map[string]Response{
+						"201": createdResponse,
+						"400": errorResponse("Invalid payload or duplicate email"),
+						"422": errorResponse("Idempotency-Key reused with a different body"),
+					},
+				},
+			},
+			"/users/{id}": {
+				"get": {
+					Summary:    "Get a user",
+					Parameters: []Parameter{userIDParam, fieldsParam, prettyParam},
+					Responses: map[string]Response{
+						"200": userResponse("User"),
+						"304": {Description: "Not modified, If-None-Match matched the ETag"},
+						"400": errorResponse("Invalid user ID or field"),
+						"404": errorResponse("User not found"),
+					},
+				},
+				"head": {
+					Summary:    "Check whether a user exists",
+					Parameters: []Parameter{userIDParam},
+					Responses: map[string]Response{
+						"200": {Description: "User exists"},
+						"404": {Description: "User not found"},
+					},
+				},
+				"put": {
+					Summary:     "Update a user",
+					Parameters:  []Parameter{userIDParam},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("UpdateUserRequest"))},
+					Responses: map[string]Response{
+						"200": userResponse("Updated user"),
+						"400": errorResponse("Invalid payload"),
+						"404": errorResponse("User not found"),
+						"409": errorResponse("Version conflict"),
+					},
+				},
+				"patch": {
+					Summary:    "Partially update a user with a JSON Merge Patch (RFC 7386), null clears phone",
+					Parameters: []Parameter{userIDParam},
+					RequestBody: &RequestBody{Required: true, Content: map[string]MediaType{
+						"application/merge-patch+json": {Schema: &Schema{
+							Type: "object",
+							Properties: map[string]*Schema{
+								"name":  {Type: "string"},
+								"email": {Type: "string"},
+								"phone": {Type: "string", Nullable: true},
+							},
+						}},
+					}},
+					Responses: map[string]Response{
+						"200": userResponse("Updated user"),
+						"400": errorResponse("Invalid patch"),
+						"404": errorResponse("User not found"),
+						"409": errorResponse("Concurrent modification"),
+					},
+				},
+				"delete": {
+					Summary:    "Soft-delete a user",
+					Parameters: []Parameter{userIDParam},
+					Responses: map[string]Response{
+						"200": {Description: "User deleted"},
+						"404": errorResponse("User not found"),
+					},
+				},
+			},
+			"/users/{id}/email": {
+				"patch": {
+					Summary:     "Change a user's email, email_verified is reset to false",
+					Parameters:  []Parameter{userIDParam},
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("UpdateEmailRequest"))},
+					Responses: map[string]Response{
+						"200": userResponse("Updated user"),
+						"400": errorResponse("Invalid payload or duplicate email"),
+						"404": errorResponse("User not found"),
+					},
+				},
+			},
+			"/users/count": {
+				"get": {
+					Summary: "Count active users",
+					Responses: map[string]Response{
+						"200": {Description: "Count", Content: jsonContent(&Schema{
+							Type:       "object",
+							Properties: map[string]*Schema{"count": {Type: "integer"}},
+						})},
+					},
+				},
+			},
+			"/users/validate": {
+				"post": {
+					Summary:     "Validate a create payload without persisting it",
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("CreateUserRequest"))},
+					Responses: map[string]Response{
+						"200": {Description: "Valid", Content: jsonContent(ref("ValidationResult"))},
+						"400": {Description: "Field errors", Content: jsonContent(ref("ValidationResult"))},
+					},
+				},
+			},
+			"/users/get-or-create": {
+				"post": {
+					Summary:     "Get the user with an email or create it",
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("CreateUserRequest"))},
+					Responses: map[string]Response{
+						"200": userResponse("Existing user"),
+						"201": createdResponse,
+						"400": errorResponse("Invalid payload"),
+					},
+				},
+			},
+			"/users/bulk": {
+				"post": {
+					Summary:     "Create several users, each item succeeds or fails on its own",
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("BulkCreateRequest"))},
+					Responses: map[string]Response{
+						"200": {Description: "One result per user in request order", Content: jsonContent(ref("BulkCreateResponse"))},
+						"400": errorResponse("Invalid payload"),
+					},
+				},
+			},
+			"/users/batch-get": {
+				"post": {
+					Summary:     "Get several users by ID",
+					RequestBody: &RequestBody{Required: true, Content: jsonContent(ref("BatchGetRequest"))},
+					Responses: map[string]Response{
+						"200": {Description: "Found users and missing IDs", Content: jsonContent(ref("BatchGetResponse"))},
+						"400": errorResponse("Invalid payload"),
+					},
+				},
+			},
+		},
+		Components: Components{
+			Schemas: map[string]*Schema{
+				"User":               schemaFor(reflect.TypeOf(User{}), "id", "name", "email", "version"),
+				"CreateUserRequest":  schemaFor(reflect.TypeOf(CreateUserRequest{}), "name", "email"),
+				"UpdateUserRequest":  schemaFor(reflect.TypeOf(UpdateUserRequest{}), "name", "email"),
+				"UpdateEmailRequest": schemaFor(reflect.TypeOf(UpdateEmailRequest{}), "email"),
+				"BatchGetRequest":    schemaFor(reflect.TypeOf(BatchGetRequest{}), "ids"),
+				"BulkCreateRequest":  schemaFor(reflect.TypeOf(BulkCreateRequest{}), "users"),
+				"BulkCreateResponse": schemaFor(reflect.TypeOf(BulkCreateResponse{}), "results"),
+				"BatchGetResponse":   schemaFor(reflect.TypeOf(BatchGetResponse{}), "users", "missing"),
+				"ValidationResult":   schemaFor(reflect.TypeOf(ValidationResult{}), "valid"),
+				"APIError":           schemaFor(reflect.TypeOf(APIError{}), "error"),
+			},
+		},
+	}
+}
+
+// SpecHandler serves the OpenAPI document at GET /openapi.json
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET method is allowed")
+		return
+	}
+	respondWithJSON(w, r, http.StatusOK, apiSpec())
+}
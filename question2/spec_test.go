@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSpecHandler tests that the spec unmarshals and documents the user endpoints
+func TestSpecHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SpecHandler(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var spec OpenAPI
+	if err := json.NewDecoder(rec.Body).Decode(&spec); err != nil {
+		t.Fatalf("spec does not unmarshal: %v", err)
+	}
+
+	operations := map[string][]string{
+		"/users":      {"get", "post"},
+		"/users/{id}": {"get", "put", "delete", "head"},
+	}
+	for path, methods := range operations {
+		item, exists := spec.Paths[path]
+		if !exists {
+			t.Errorf("missing path %s", path)
+			continue
+		}
+		for _, method := range methods {
+			if _, exists := item[method]; !exists {
+				t.Errorf("missing %s %s", method, path)
+			}
+		}
+	}
+
+	user := spec.Components.Schemas["User"]
+	if user == nil || user.Properties["email"] == nil || !user.Properties["deleted_at"].Nullable {
+		t.Errorf("User schema not derived from the struct: %+v", user)
+	}
+	if _, exists := user.Properties["XMLName"]; exists {
+		t.Error("fields tagged json:\"-\" should be skipped")
+	}
+}
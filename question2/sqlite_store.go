@@ -0,0 +1,357 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteSchema creates the users table, the unique index keeps lowercased emails unique
+// including soft-deleted users, matching UserStore's canonicalEmail (emails are stored trimmed).
+// Opening an older database replaces its exact-match index, which fails if it holds emails
+// differing only in case
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS users (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT NOT NULL,
+	email      TEXT NOT NULL,
+	phone      TEXT NOT NULL DEFAULT '',
+	version    INTEGER NOT NULL DEFAULT 1,
+	deleted_at DATETIME,
+	email_verified INTEGER NOT NULL DEFAULT 0
+);
+DROP INDEX IF EXISTS users_email;
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_canonical ON users (lower(email));
+`
+
+// sqliteMigrations adds columns introduced after the first schema to existing databases
+var sqliteMigrations = []struct{ column, ddl string }{
+	{"email_verified", "ALTER TABLE users ADD COLUMN email_verified INTEGER NOT NULL DEFAULT 0"},
+}
+
+// userColumns is the column list scanned by scanUser
+const userColumns = "id, name, email, phone, version, deleted_at, email_verified"
+
+// SQLiteStore persists users in SQLite through database/sql, using the pure Go
+// modernc.org/sqlite driver so no cgo is needed.
+// Read methods follow the Store signatures, so database errors are logged and reported as not found
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (or creates) the database at dsn, e.g. "users.db" or ":memory:"
+func NewSQLiteStore(dsn string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, and every connection to ":memory:" is a new database
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrateSQLite adds the sqliteMigrations columns that an older users table lacks
+func migrateSQLite(db *sql.DB) error {
+	for _, m := range sqliteMigrations {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('users') WHERE name = ?", m.column).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if _, err := db.Exec(m.ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the database
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Reset deletes every user and resets the AUTOINCREMENT counter so the next user gets ID 1
+func (s *SQLiteStore) Reset() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM users"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM sqlite_sequence WHERE name = 'users'"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Create inserts a new user, returning ErrEmailExists if the email is taken
+func (s *SQLiteStore) Create(name, email, phone string) (*User, error) {
+	email = strings.TrimSpace(email)
+	res, err := s.db.Exec("INSERT INTO users (name, email, phone) VALUES (?, ?, ?)", name, email, phone)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
+		return nil, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &User{ID: UserID(strconv.FormatInt(id, 10)), Name: name, Email: email, Phone: phone, Version: 1}, nil
+}
+
+// GetOrCreate returns the active user with email, or creates one when the email is unused.
+// The unique email index makes the insert the arbiter, so concurrent callers get the same user
+func (s *SQLiteStore) GetOrCreate(name, email string) (*User, bool, error) {
+	user, err := s.Create(name, email, "")
+	if err == nil {
+		return user, true, nil
+	}
+	if !errors.Is(err, ErrEmailExists) {
+		return nil, false, err
+	}
+
+	// the email is taken, either by an active user we return or by a soft-deleted one
+	if user, exists := s.FindByEmail(email); exists {
+		return user, false, nil
+	}
+	return nil, false, ErrEmailExists
+}
+
+// Get retrieves a user by ID, soft-deleted users are not returned
+func (s *SQLiteStore) Get(id UserID) (*User, bool) {
+	row := s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NULL", id)
+	return s.scanOne(row)
+}
+
+// GetMany retrieves several active users with one query. Found users keep the order
+// of ids and missing (or soft-deleted) IDs are returned separately, duplicate IDs are reported once
+func (s *SQLiteStore) GetMany(ids []UserID) ([]*User, []UserID) {
+	found, missing := []*User{}, []UserID{}
+	if len(ids) == 0 {
+		return found, missing
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	byID := make(map[UserID]*User, len(ids))
+	rows, err := s.db.Query("SELECT "+userColumns+" FROM users WHERE id IN ("+placeholders+") AND deleted_at IS NULL", args...)
+	if err != nil {
+		log.Printf("sqlite: get many users: %v", err)
+	} else {
+		defer rows.Close()
+		for rows.Next() {
+			user, err := scanUser(rows)
+			if err != nil {
+				log.Printf("sqlite: scan user: %v", err)
+				continue
+			}
+			byID[user.ID] = user
+		}
+	}
+
+	seen := make(map[UserID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if user, exists := byID[id]; exists {
+			found = append(found, user)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return found, missing
+}
+
+// FindByEmail retrieves an active user by email
+func (s *SQLiteStore) FindByEmail(email string) (*User, bool) {
+	row := s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE lower(email) = lower(?) AND deleted_at IS NULL", strings.TrimSpace(email))
+	return s.scanOne(row)
+}
+
+// List returns users ordered by ID, soft-deleted users only when includeDeleted is set
+func (s *SQLiteStore) List(includeDeleted bool) []*User {
+	query := "SELECT " + userColumns + " FROM users WHERE deleted_at IS NULL ORDER BY id"
+	if includeDeleted {
+		query = "SELECT " + userColumns + " FROM users ORDER BY id"
+	}
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		log.Printf("sqlite: list users: %v", err)
+		return []*User{}
+	}
+	defer rows.Close()
+
+	users := []*User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			log.Printf("sqlite: scan user: %v", err)
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+// Count returns the number of active users
+func (s *SQLiteStore) Count() int {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL").Scan(&count); err != nil {
+		log.Printf("sqlite: count users: %v", err)
+	}
+	return count
+}
+
+// Update modifies an existing active user, returning ErrEmailExists if another user has the email
+func (s *SQLiteStore) Update(id UserID, name, email, phone string) (*User, bool, error) {
+	email = strings.TrimSpace(email)
+	res, err := s.db.Exec(
+		"UPDATE users SET name = ?, email = ?, phone = ?, email_verified = email_verified AND lower(email) = lower(?), version = version + 1 WHERE id = ? AND deleted_at IS NULL",
+		name, email, phone, email, id)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, true, ErrEmailExists
+		}
+		return nil, false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, false, nil
+	}
+	user, exists := s.Get(id)
+	return user, exists, nil
+}
+
+// UpdateIfVersion modifies an existing user only if its current version matches
+func (s *SQLiteStore) UpdateIfVersion(id UserID, name, email, phone string, version int) (*User, error) {
+	email = strings.TrimSpace(email)
+	res, err := s.db.Exec(
+		"UPDATE users SET name = ?, email = ?, phone = ?, email_verified = email_verified AND lower(email) = lower(?), version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL",
+		name, email, phone, email, id, version)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrEmailExists
+		}
+		return nil, err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		// the conditional update matched nothing, find out why
+		if _, exists := s.Get(id); !exists {
+			return nil, ErrUserNotFound
+		}
+		return nil, ErrVersionConflict
+	}
+
+	user, _ := s.Get(id)
+	return user, nil
+}
+
+// UpdateEmail changes only the email of an active user and marks it unverified,
+// setting the current email again changes nothing
+func (s *SQLiteStore) UpdateEmail(id UserID, email string) (*User, bool, error) {
+	email = strings.TrimSpace(email)
+	_, err := s.db.Exec(
+		"UPDATE users SET email = ?, email_verified = 0, version = version + 1 WHERE id = ? AND lower(email) <> lower(?) AND deleted_at IS NULL",
+		email, id, email)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, true, ErrEmailExists
+		}
+		return nil, false, err
+	}
+
+	// no row changed when the user is missing or already has this email
+	user, exists := s.Get(id)
+	return user, exists, nil
+}
+
+// SoftDelete marks a user as deleted while keeping the row
+func (s *SQLiteStore) SoftDelete(id UserID) bool {
+	return s.execAffected("UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), id)
+}
+
+// Restore clears the soft-delete flag of a user
+func (s *SQLiteStore) Restore(id UserID) bool {
+	return s.execAffected("UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+}
+
+// Delete permanently removes a user
+func (s *SQLiteStore) Delete(id UserID) bool {
+	return s.execAffected("DELETE FROM users WHERE id = ?", id)
+}
+
+// execAffected runs a statement and reports whether it changed any row
+func (s *SQLiteStore) execAffected(query string, args ...interface{}) bool {
+	res, err := s.db.Exec(query, args...)
+	if err != nil {
+		log.Printf("sqlite: %v", err)
+		return false
+	}
+	n, _ := res.RowsAffected()
+	return n > 0
+}
+
+// scanOne scans a single-row query result
+func (s *SQLiteStore) scanOne(row *sql.Row) (*User, bool) {
+	user, err := scanUser(row)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("sqlite: scan user: %v", err)
+		}
+		return nil, false
+	}
+	return user, true
+}
+
+// scanUser scans the userColumns of a row into a User
+func scanUser(row interface {
+	Scan(dest ...interface{}) error
+}) (*User, error) {
+	var user User
+	var id int64 // the table uses AUTOINCREMENT, so IDs are always sequential
+	var deletedAt sql.NullTime
+	if err := row.Scan(&id, &user.Name, &user.Email, &user.Phone, &user.Version, &deletedAt, &user.EmailVerified); err != nil {
+		return nil, err
+	}
+	user.ID = UserID(strconv.FormatInt(id, 10))
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	return &user, nil
+}
+
+// isUniqueViolation reports whether err is a SQLite unique constraint failure
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
@@ -0,0 +1,29 @@
+package main
+
+// Store is the user persistence the handlers depend on, so backends can be swapped
+// and handlers can be unit tested with a fake. UserStore (in memory) and SQLiteStore implement it.
+// Emails are stored trimmed and are unique in their canonical form, see canonicalEmail
+type Store interface {
+	Create(name, email, phone string) (*User, error)
+	GetOrCreate(name, email string) (*User, bool, error)
+	Get(id UserID) (*User, bool)
+	GetMany(ids []UserID) ([]*User, []UserID)
+	FindByEmail(email string) (*User, bool)
+	List(includeDeleted bool) []*User
+	Count() int
+	Update(id UserID, name, email, phone string) (*User, bool, error)
+	UpdateIfVersion(id UserID, name, email, phone string, version int) (*User, error)
+	UpdateEmail(id UserID, email string) (*User, bool, error)
+	SoftDelete(id UserID) bool
+	Restore(id UserID) bool
+	Delete(id UserID) bool
+	// Reset removes every user, soft-deleted ones included, and restarts IDs at 1
+	Reset() error
+	// Close releases the store, later mutations fail
+	Close() error
+}
+
+var (
+	_ Store = (*UserStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
@@ -0,0 +1,420 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// storeFactories creates a fresh instance of every Store implementation
+var storeFactories = map[string]func(t *testing.T) Store{
+	"memory": func(t *testing.T) Store {
+		return NewUserStore()
+	},
+	"sqlite": func(t *testing.T) Store {
+		store, err := NewSQLiteStore(":memory:")
+		if err != nil {
+			t.Fatalf("NewSQLiteStore failed: %v", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	},
+}
+
+// TestStore runs the same behavior suite against every Store implementation
+func TestStore(t *testing.T) {
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Run("create and get", func(t *testing.T) {
+				store := newStore(t)
+				created, err := store.Create("John", "john@example.com", "+6281234567890")
+				if err != nil {
+					t.Fatalf("Create failed: %v", err)
+				}
+				if created.ID != "1" || created.Version != 1 {
+					t.Errorf("unexpected created user: %+v", created)
+				}
+
+				user, exists := store.Get(created.ID)
+				if !exists || user.Email != "john@example.com" || user.Phone != "+6281234567890" {
+					t.Errorf("unexpected user: %+v", user)
+				}
+				if _, exists := store.FindByEmail("john@example.com"); !exists {
+					t.Error("FindByEmail should find the user")
+				}
+			})
+
+			t.Run("duplicate email", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+				if _, err := store.Create("Johnny", "john@example.com", ""); !errors.Is(err, ErrEmailExists) {
+					t.Errorf("expected ErrEmailExists, got %v", err)
+				}
+			})
+
+			t.Run("update and version", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+
+				user, ok, err := store.Update("1", "John Smith", "john@example.com", "")
+				if !ok || err != nil || user.Name != "John Smith" || user.Version != 2 {
+					t.Errorf("unexpected updated user: %+v", user)
+				}
+				if _, err := store.UpdateIfVersion("1", "Stale", "john@example.com", "", 1); !errors.Is(err, ErrVersionConflict) {
+					t.Errorf("expected ErrVersionConflict, got %v", err)
+				}
+				if _, err := store.UpdateIfVersion("99", "Nobody", "x@example.com", "", 1); !errors.Is(err, ErrUserNotFound) {
+					t.Errorf("expected ErrUserNotFound, got %v", err)
+				}
+				if _, ok, err := store.Update("99", "Nobody", "x@example.com", ""); ok || err != nil {
+					t.Error("updating a missing user should fail")
+				}
+			})
+
+			t.Run("soft delete, list and restore", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+				store.Create("Jane", "jane@example.com", "")
+
+				if !store.SoftDelete("1") || store.SoftDelete("1") {
+					t.Error("SoftDelete should succeed exactly once")
+				}
+				if _, exists := store.Get("1"); exists {
+					t.Error("soft-deleted user should be hidden")
+				}
+				if store.Count() != 1 || len(store.List(false)) != 1 {
+					t.Errorf("expected 1 active user, count %d", store.Count())
+				}
+
+				all := store.List(true)
+				if len(all) != 2 || all[0].ID != "1" || all[0].DeletedAt == nil {
+					t.Errorf("include deleted should list both users ordered by ID, got %+v", all)
+				}
+
+				if !store.Restore("1") {
+					t.Error("Restore should succeed")
+				}
+				if _, exists := store.Get("1"); !exists {
+					t.Error("restored user should be visible")
+				}
+			})
+
+			t.Run("get or create", func(t *testing.T) {
+				store := newStore(t)
+
+				var wg sync.WaitGroup
+				ids := make(chan UserID, 20)
+				var createdCount atomic.Int64
+				for i := 0; i < 20; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						user, created, err := store.GetOrCreate("John", "john@example.com")
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						if created {
+							createdCount.Add(1)
+						}
+						ids <- user.ID
+					}()
+				}
+				wg.Wait()
+				close(ids)
+
+				if createdCount.Load() != 1 {
+					t.Errorf("expected exactly 1 create, got %d", createdCount.Load())
+				}
+				for id := range ids {
+					if id != "1" {
+						t.Errorf("every caller should get user 1, got %s", id)
+					}
+				}
+
+				store.Create("Jane", "jane@example.com", "")
+				store.SoftDelete("2")
+				if _, _, err := store.GetOrCreate("Jane", "jane@example.com"); !errors.Is(err, ErrEmailExists) {
+					t.Errorf("a soft-deleted email should stay reserved, got %v", err)
+				}
+			})
+
+			t.Run("get many", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+				store.Create("Jane", "jane@example.com", "")
+				store.Create("Jim", "jim@example.com", "")
+				store.SoftDelete("3")
+
+				users, missing := store.GetMany([]UserID{"2", "99", "1", "3", "2"})
+				if len(users) != 2 || users[0].ID != "2" || users[1].ID != "1" {
+					t.Errorf("expected users 2 and 1 in request order, got %+v", users)
+				}
+				if len(missing) != 2 || missing[0] != "99" || missing[1] != "3" {
+					t.Errorf("expected missing [99 3], got %v", missing)
+				}
+			})
+
+			t.Run("update email", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+				store.Create("Jane", "jane@example.com", "")
+				markEmailVerified(t, store, "1")
+
+				if user, _ := store.Get("1"); !user.EmailVerified {
+					t.Fatal("expected the user to start verified")
+				}
+				user, exists, err := store.UpdateEmail("1", "john@example.com")
+				if err != nil || !exists || !user.EmailVerified || user.Version != 1 {
+					t.Errorf("setting the same email should change nothing, got %+v %v %v", user, exists, err)
+				}
+
+				user, exists, err = store.UpdateEmail("1", "john.new@example.com")
+				if err != nil || !exists {
+					t.Fatalf("UpdateEmail failed: %v %v", exists, err)
+				}
+				if user.Email != "john.new@example.com" || user.EmailVerified || user.Version != 2 {
+					t.Errorf("unexpected user after email change: %+v", user)
+				}
+
+				if _, exists, err := store.UpdateEmail("1", "jane@example.com"); !exists || !errors.Is(err, ErrEmailExists) {
+					t.Errorf("expected ErrEmailExists, got %v %v", exists, err)
+				}
+				if _, exists, err := store.UpdateEmail("99", "nobody@example.com"); exists || err != nil {
+					t.Errorf("expected a missing user, got %v %v", exists, err)
+				}
+
+				// A PUT that changes the email resets the flag as well
+				markEmailVerified(t, store, "2")
+				if user, _, _ := store.Update("2", "Jane", "jane@example.com", ""); !user.EmailVerified {
+					t.Error("an update keeping the email should keep it verified")
+				}
+				if user, _, _ := store.Update("2", "Jane", "jane.new@example.com", ""); user.EmailVerified {
+					t.Error("an update changing the email should reset verification")
+				}
+			})
+
+			t.Run("hard delete", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+				if !store.Delete("1") || store.Delete("1") {
+					t.Error("Delete should succeed exactly once")
+				}
+				if len(store.List(true)) != 0 {
+					t.Error("hard-deleted user should be gone")
+				}
+			})
+
+			t.Run("canonical email", func(t *testing.T) {
+				store := newStore(t)
+				created, err := store.Create("John", "  John@X.com ", "")
+				if err != nil || created.Email != "John@X.com" {
+					t.Fatalf("expected the email stored trimmed, got %+v (err %v)", created, err)
+				}
+				if _, err := store.Create("Johnny", "john@x.com", ""); !errors.Is(err, ErrEmailExists) {
+					t.Errorf("Create: expected ErrEmailExists for a case/whitespace variant, got %v", err)
+				}
+				if user, exists := store.FindByEmail(" JOHN@x.com"); !exists || user.ID != created.ID {
+					t.Errorf("FindByEmail should match the canonical form, got %+v", user)
+				}
+
+				store.Create("Jane", "jane@example.com", "")
+				if _, _, err := store.Update("2", "Jane", " JOHN@x.com", ""); !errors.Is(err, ErrEmailExists) {
+					t.Errorf("Update: expected ErrEmailExists, got %v", err)
+				}
+				if _, err := store.UpdateIfVersion("2", "Jane", "John@x.COM", "", 1); !errors.Is(err, ErrEmailExists) {
+					t.Errorf("UpdateIfVersion: expected ErrEmailExists, got %v", err)
+				}
+				if _, _, err := store.UpdateEmail("2", "john@x.com"); !errors.Is(err, ErrEmailExists) {
+					t.Errorf("UpdateEmail: expected ErrEmailExists, got %v", err)
+				}
+
+				// a user may change the case of its own email
+				if user, _, err := store.Update("1", "John", "john@x.com", ""); err != nil || user.Email != "john@x.com" {
+					t.Errorf("expected the own email in another case to be accepted, got %+v (err %v)", user, err)
+				}
+			})
+
+			t.Run("reset", func(t *testing.T) {
+				store := newStore(t)
+				store.Create("John", "john@example.com", "")
+				store.Create("Jane", "jane@example.com", "")
+				store.SoftDelete("2")
+				if err := store.Reset(); err != nil {
+					t.Fatalf("Reset failed: %v", err)
+				}
+				if len(store.List(true)) != 0 {
+					t.Error("Reset should remove every user, soft-deleted ones included")
+				}
+				user, err := store.Create("John", "john@example.com", "")
+				if err != nil || user.ID != "1" {
+					t.Errorf("expected the email to be free and IDs to restart at 1, got %+v (err %v)", user, err)
+				}
+			})
+		})
+	}
+}
+
+// TestUserStore_ConcurrentStress hammers UserStore from hundreds of goroutines, run it with -race
+func TestUserStore_ConcurrentStress(t *testing.T) {
+	log.SetOutput(io.Discard) // Create logs every new ID
+	defer log.SetOutput(os.Stderr)
+
+	t.Run("create", func(t *testing.T) {
+		store := NewUserStore()
+		const creators = 500
+
+		var wg sync.WaitGroup
+		ids := make(chan UserID, creators)
+		for i := 0; i < creators; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user, err := store.Create("User", fmt.Sprintf("user%d@example.com", i), "")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids <- user.ID
+			}(i)
+		}
+		wg.Wait()
+		close(ids)
+
+		// IDs must be unique and gap-free
+		seen := make(map[UserID]bool, creators)
+		for id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate ID %s", id)
+			}
+			seen[id] = true
+		}
+		for id := 1; id <= creators; id++ {
+			if !seen[UserID(strconv.Itoa(id))] {
+				t.Errorf("ID %d was never handed out", id)
+			}
+		}
+		if store.Count() != creators {
+			t.Errorf("expected %d users, got %d", creators, store.Count())
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		store := NewUserStore()
+		const seeded, workers, rounds = 100, 400, 5
+		for i := 0; i < seeded; i++ {
+			store.Create("Seed", fmt.Sprintf("seed%d@example.com", i), "")
+		}
+
+		var wg sync.WaitGroup
+		var deleted atomic.Int64
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				email := fmt.Sprintf("worker%d@example.com", i)
+				user, err := store.Create("Worker", email, "")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				// Read a seeded user that other goroutines are updating concurrently
+				n := i%seeded + 1
+				seed := UserID(strconv.Itoa(n))
+				for j := 0; j < rounds; j++ {
+					u, ok := store.Get(seed)
+					store.Update(seed, fmt.Sprintf("Seed %d", i), fmt.Sprintf("seed%d@example.com", n-1), "")
+					runtime.Gosched()
+					if !ok || u.ID != seed || u.Name == "" {
+						t.Errorf("unexpected seeded user %s: %+v", seed, u)
+					}
+				}
+
+				updated, ok, err := store.Update(user.ID, "Worker Updated", email, "")
+				if !ok || err != nil || updated.Version != 2 {
+					t.Errorf("unexpected update of user %s: %+v", user.ID, updated)
+				}
+				store.List(false)
+
+				if i%4 == 0 {
+					if !store.Delete(user.ID) {
+						t.Errorf("delete of user %s failed", user.ID)
+					}
+					deleted.Add(1)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		want := seeded + workers - int(deleted.Load())
+		if store.Count() != want {
+			t.Errorf("expected %d users, got %d", want, store.Count())
+		}
+
+		seen := make(map[UserID]bool)
+		for _, user := range store.List(true) {
+			if seen[user.ID] {
+				t.Errorf("duplicate ID %s", user.ID)
+			}
+			seen[user.ID] = true
+		}
+		for id := 1; id <= seeded; id++ {
+			if user, _ := store.Get(UserID(strconv.Itoa(id))); user == nil || user.Version != 1+rounds*workers/seeded {
+				t.Errorf("expected seeded user %d at version %d, got %+v", id, 1+rounds*workers/seeded, user)
+			}
+		}
+	})
+}
+
+// markEmailVerified sets email_verified directly, there is no API for it yet
+func markEmailVerified(t *testing.T, store Store, id UserID) {
+	t.Helper()
+	switch s := store.(type) {
+	case *UserStore:
+		s.mu.Lock()
+		s.users[id].EmailVerified = true
+		s.mu.Unlock()
+	case *SQLiteStore:
+		if _, err := s.db.Exec("UPDATE users SET email_verified = 1 WHERE id = ?", id); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Fatalf("unsupported store %T", store)
+	}
+}
+
+// TestSQLiteStore_Migrate tests that a database created before email_verified existed is upgraded
+func TestSQLiteStore_Migrate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, email TEXT NOT NULL,
+		phone TEXT NOT NULL DEFAULT '', version INTEGER NOT NULL DEFAULT 1, deleted_at DATETIME);
+		INSERT INTO users (name, email) VALUES ('John', 'john@example.com');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore failed on an old database: %v", err)
+	}
+	defer store.Close()
+	if user, exists := store.Get("1"); !exists || user.EmailVerified {
+		t.Errorf("expected the existing user unverified, got %+v", user)
+	}
+}
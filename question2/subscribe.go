@@ -0,0 +1,69 @@
+package main
+
+import "errors"
+
+// ErrStoreClosed is returned by mutations once the store has been closed
+var ErrStoreClosed = errors.New("user store is closed")
+
+// ChangeKind says what happened to a user
+type ChangeKind string
+
+const (
+	ChangeCreated ChangeKind = "created"
+	ChangeUpdated ChangeKind = "updated" // includes restoring a soft-deleted user
+	ChangeDeleted ChangeKind = "deleted" // soft or permanent
+)
+
+// UserChange is sent to subscribers after every mutation, User is a copy of the user after the change
+type UserChange struct {
+	Kind ChangeKind
+	User *User
+}
+
+// subscriberBuffer is how many changes a subscriber may fall behind before changes are dropped for it
+const subscriberBuffer = 64
+
+// Subscribe returns a channel receiving every later change to the store. Changes are sent while
+// the write lock is held, so a subscriber that falls more than subscriberBuffer changes behind
+// misses changes instead of blocking writers. The channel is closed by Close, which ends a
+// range loop over it. Subscribing to a closed store returns an already closed channel
+func (s *UserStore) Subscribe() <-chan UserChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ch := make(chan UserChange, subscriberBuffer)
+	if s.closed {
+		close(ch)
+		return ch
+	}
+	s.subscribers = append(s.subscribers, ch)
+	return ch
+}
+
+// publish sends a change to every subscriber without blocking, the caller must hold the write lock
+func (s *UserStore) publish(kind ChangeKind, user *User) {
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- UserChange{Kind: kind, User: user.clone()}:
+		default:
+		}
+	}
+}
+
+// Close closes every subscriber channel and marks the store closed. Later mutations fail with
+// ErrStoreClosed, or report false where they only return a bool, reads keep working.
+// Closing twice is a no-op
+func (s *UserStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	for _, ch := range s.subscribers {
+		close(ch)
+	}
+	s.subscribers = nil
+	return nil
+}
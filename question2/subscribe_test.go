@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestUserStore_Subscribe tests that subscribers receive every kind of change in order
+func TestUserStore_Subscribe(t *testing.T) {
+	store := NewUserStore()
+	changes := store.Subscribe()
+
+	user, _ := store.Create("John Doe", "john@example.com", "")
+	store.Update(user.ID, "John Smith", "john@example.com", "")
+	store.SoftDelete(user.ID)
+	store.Restore(user.ID)
+	store.Delete(user.ID)
+
+	want := []ChangeKind{ChangeCreated, ChangeUpdated, ChangeDeleted, ChangeUpdated, ChangeDeleted}
+	for i, kind := range want {
+		change := <-changes
+		if change.Kind != kind || change.User.ID != user.ID {
+			t.Errorf("change %d: expected %s of user %s, got %s of user %s", i, kind, user.ID, change.Kind, change.User.ID)
+		}
+	}
+	select {
+	case change := <-changes:
+		t.Errorf("unexpected extra change: %+v", change)
+	default:
+	}
+}
+
+// TestUserStore_Close tests that Close ends a ranging subscriber and later mutations fail
+func TestUserStore_Close(t *testing.T) {
+	store := NewUserStore()
+	user, _ := store.Create("John Doe", "john@example.com", "")
+	changes := store.Subscribe()
+
+	done := make(chan int)
+	go func() {
+		received := 0
+		for range changes {
+			received++
+		}
+		done <- received
+	}()
+
+	store.Update(user.ID, "John Smith", "john@example.com", "")
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	select {
+	case received := <-done:
+		if received != 1 {
+			t.Errorf("expected 1 change before Close, got %d", received)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscriber loop did not exit after Close")
+	}
+
+	if _, err := store.Create("Jane Doe", "jane@example.com", ""); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("expected ErrStoreClosed from Create, got %v", err)
+	}
+	if _, _, err := store.Update(user.ID, "Jane", "john@example.com", ""); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("expected ErrStoreClosed from Update, got %v", err)
+	}
+	if err := store.Transaction(func(tx *Tx) error { return nil }); !errors.Is(err, ErrStoreClosed) {
+		t.Errorf("expected ErrStoreClosed from Transaction, got %v", err)
+	}
+	if got, exists := store.Get(user.ID); !exists || got.Name != "John Smith" {
+		t.Errorf("reads should keep working after Close, got %+v, %v", got, exists)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, open := <-store.Subscribe(); open {
+		t.Error("Subscribe after Close should return a closed channel")
+	}
+}
+
+// TestHandlers_StoreClosed tests that every write handler answers 503 once the store is closed
+func TestHandlers_StoreClosed(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John Doe", "john@example.com", "")
+	h := NewUserHandler(store)
+	store.Close()
+
+	tests := []struct {
+		method, path, body string
+	}{
+		{http.MethodPost, "/users", `{"name":"Jane Doe","email":"jane@example.com"}`},
+		{http.MethodPost, "/users/get-or-create", `{"name":"Jane Doe","email":"jane@example.com"}`},
+		{http.MethodPut, "/users/1", `{"name":"John Smith","email":"john@example.com"}`},
+		{http.MethodPut, "/users/1", `{"name":"John Smith","email":"john@example.com","version":1}`},
+		{http.MethodPatch, "/users/1", `{"name":"John Smith"}`},
+		{http.MethodPatch, "/users/1/email", `{"email":"john.new@example.com"}`},
+	}
+	for _, tt := range tests {
+		if rec := doRequest(h, tt.method, tt.path, tt.body); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s %s: expected 503, got %d: %s", tt.method, tt.path, tt.body, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ResetStore(rec, httptest.NewRequest(http.MethodPost, "/admin/reset", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /admin/reset: expected 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tx stages user changes inside UserStore.Transaction. Staged changes are only
+// written to the store when the transaction function returns nil
+type Tx struct {
+	store  *UserStore
+	staged map[UserID]*User // staged creates and updates, a nil value is a staged delete
+}
+
+// Transaction runs fn with a Tx and commits all staged changes atomically if fn returns nil,
+// otherwise nothing is persisted and fn's error is returned.
+// fn runs while the store's write lock is held, so it must only use tx and never call the store directly.
+// IDs taken by a rolled-back transaction are reused only if the IDGenerator supports it, as the default one does
+func (s *UserStore) Transaction(fn func(tx *Tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+	rewinder, canRewind := s.ids.(idRewinder)
+	var mark int
+	if canRewind {
+		mark = rewinder.mark()
+	}
+
+	tx := &Tx{store: s, staged: make(map[UserID]*User)}
+	if err := fn(tx); err != nil {
+		// rollback, the staged changes are simply dropped
+		if canRewind {
+			rewinder.rewind(mark)
+		}
+		return err
+	}
+
+	for id, user := range tx.staged {
+		stored, existed := s.users[id]
+		switch {
+		case user == nil:
+			if existed { // a user created and deleted in the same transaction was never visible
+				delete(s.users, id)
+				s.publish(ChangeDeleted, stored)
+			}
+		case existed:
+			s.users[id] = user
+			s.publish(ChangeUpdated, user)
+		default:
+			s.users[id] = user
+			s.publish(ChangeCreated, user)
+		}
+	}
+	return nil
+}
+
+// Create stages a new user, the email must be unique among stored and staged users
+func (tx *Tx) Create(name, email, phone string) (*User, error) {
+	if tx.store.maxUsers > 0 && tx.size() >= tx.store.maxUsers {
+		return nil, ErrStoreFull
+	}
+	email = strings.TrimSpace(email)
+	if _, taken := tx.emailOwner(email); taken {
+		return nil, ErrEmailExists
+	}
+
+	id := tx.store.ids.Next()
+	if _, exists := tx.lookup(id); exists {
+		return nil, fmt.Errorf("id generator returned id %s which is already in use", id)
+	}
+
+	user := &User{
+		ID:      id,
+		Name:    name,
+		Email:   email,
+		Phone:   phone,
+		Version: 1,
+	}
+	tx.staged[user.ID] = user
+	return user.clone(), nil
+}
+
+// Update stages a change to an existing user, the email must stay unique like in Create
+func (tx *Tx) Update(id UserID, name, email, phone string) (*User, bool, error) {
+	current, exists := tx.lookup(id)
+	if !exists || current.DeletedAt != nil {
+		return nil, false, nil
+	}
+	email = strings.TrimSpace(email)
+	if owner, taken := tx.emailOwner(email); taken && owner != id {
+		return nil, true, ErrEmailExists
+	}
+
+	// stage a copy so a rollback leaves the stored user untouched
+	user := *current
+	if canonicalEmail(user.Email) != canonicalEmail(email) {
+		user.EmailVerified = false
+	}
+	user.Name = name
+	user.Email = email
+	user.Phone = phone
+	user.Version++
+	tx.staged[id] = &user
+	return user.clone(), true, nil
+}
+
+// Delete stages the permanent removal of a user
+func (tx *Tx) Delete(id UserID) bool {
+	if _, exists := tx.lookup(id); !exists {
+		return false
+	}
+	tx.staged[id] = nil
+	return true
+}
+
+// size returns how many users the store would hold if the transaction committed now
+func (tx *Tx) size() int {
+	size := len(tx.store.users)
+	for id, user := range tx.staged {
+		_, stored := tx.store.users[id]
+		switch {
+		case user == nil && stored:
+			size--
+		case user != nil && !stored:
+			size++
+		}
+	}
+	return size
+}
+
+// lookup returns a user as seen by the transaction, staged changes first
+func (tx *Tx) lookup(id UserID) (*User, bool) {
+	if user, staged := tx.staged[id]; staged {
+		return user, user != nil
+	}
+	user, exists := tx.store.users[id]
+	return user, exists
+}
+
+// emailOwner returns the ID of the user with the email in canonical form, checking stored users
+// that aren't staged over, then staged users
+func (tx *Tx) emailOwner(email string) (UserID, bool) {
+	canonical := canonicalEmail(email)
+	for id, user := range tx.store.users {
+		if _, staged := tx.staged[id]; staged {
+			continue
+		}
+		if canonicalEmail(user.Email) == canonical {
+			return id, true
+		}
+	}
+	for id, user := range tx.staged {
+		if user != nil && canonicalEmail(user.Email) == canonical {
+			return id, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestTransaction_RollbackOnError tests that a failing transaction leaves the store unchanged
+func TestTransaction_RollbackOnError(t *testing.T) {
+	store := NewUserStore()
+	store.Create("John", "john@example.com", "")
+
+	errAbort := errors.New("abort")
+	err := store.Transaction(func(tx *Tx) error {
+		if _, err := tx.Create("Jane", "jane@example.com", ""); err != nil {
+			return err
+		}
+		tx.Update("1", "John Updated", "john@example.com", "")
+		tx.Delete("1")
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("expected the transaction error, got %v", err)
+	}
+
+	if store.Count() != 1 {
+		t.Errorf("expected 1 user after rollback, got %d", store.Count())
+	}
+	if user, _ := store.Get("1"); user == nil || user.Name != "John" {
+		t.Errorf("user 1 must be unchanged after rollback, got %+v", user)
+	}
+
+	// The rolled back ID is reused
+	user, _ := store.Create("Jim", "jim@example.com", "")
+	if user.ID != "2" {
+		t.Errorf("expected next ID 2 after rollback, got %s", user.ID)
+	}
+}
+
+// TestTransaction_Commit tests that all staged changes are applied together
+func TestTransaction_Commit(t *testing.T) {
+	store := NewUserStore()
+
+	err := store.Transaction(func(tx *Tx) error {
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			if _, err := tx.Create("User", email, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if store.Count() != 3 {
+		t.Errorf("expected 3 users, got %d", store.Count())
+	}
+}
+
+// TestTransaction_StagedEmailUniqueness tests that a duplicate of a staged email fails the whole batch
+func TestTransaction_StagedEmailUniqueness(t *testing.T) {
+	store := NewUserStore()
+
+	err := store.Transaction(func(tx *Tx) error {
+		if _, err := tx.Create("John", "john@example.com", ""); err != nil {
+			return err
+		}
+		_, err := tx.Create("Johnny", "john@example.com", "")
+		return err
+	})
+	if !errors.Is(err, ErrEmailExists) {
+		t.Fatalf("expected ErrEmailExists, got %v", err)
+	}
+	if store.Count() != 0 {
+		t.Errorf("nothing should be persisted, got %d users", store.Count())
+	}
+}
+
+// TestTransaction_Limit tests that staged creates and deletes count towards the store limit
+func TestTransaction_Limit(t *testing.T) {
+	store := NewUserStoreWithLimit(2)
+	store.Create("John", "john@example.com", "")
+
+	err := store.Transaction(func(tx *Tx) error {
+		if _, err := tx.Create("Jane", "jane@example.com", ""); err != nil {
+			return err
+		}
+		if _, err := tx.Create("Jim", "jim@example.com", ""); !errors.Is(err, ErrStoreFull) {
+			t.Errorf("expected ErrStoreFull, got %v", err)
+		}
+		tx.Delete("1")
+		_, err := tx.Create("Jim", "jim@example.com", "")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("a staged delete should free a slot, got %v", err)
+	}
+	if store.Count() != 2 {
+		t.Errorf("expected 2 users, got %d", store.Count())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// NewUserStoreFromFile creates a UserStore holding the users of a JSON file containing an array
+// of users, as returned by GET /users?include_deleted=true. The file is validated first so a
+// hand-edited or corrupted one can't break the store's invariants
+func NewUserStoreFromFile(path string) (*UserStore, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	store := NewUserStore()
+	if err := store.loadUsers(f); err != nil {
+		return nil, fmt.Errorf("load users from %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// loadUsers decodes a JSON array of users and adds them to the store. Nothing is added unless
+// every ID is a positive number or a UUID and unique and no email is used twice, also among the users already stored.
+// A sequential ID generator continues after the highest loaded ID
+func (s *UserStore) loadUsers(r io.Reader) error {
+	var users []*User
+	if err := json.NewDecoder(r).Decode(&users); err != nil {
+		return fmt.Errorf("decode users: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrStoreClosed
+	}
+
+	loaded, maxID, err := checkUsers(users, s.users)
+	if err != nil {
+		return err
+	}
+
+	for id, user := range loaded {
+		s.users[id] = user
+	}
+	// generators that can't be moved forward fall back to create's collision check
+	if rewinder, ok := s.ids.(idRewinder); ok && rewinder.mark() < maxID {
+		rewinder.rewind(maxID)
+	}
+	return nil
+}
+
+// checkUsers indexes users by ID, checking that every ID is a positive number or a UUID and unique
+// and that no email is used twice in canonical form, also among existing. It trims the emails,
+// defaults a missing version to 1 and returns the highest sequential ID
+func checkUsers(users []*User, existing map[UserID]*User) (map[UserID]*User, int, error) {
+	byEmail := make(map[string]UserID, len(existing)+len(users)) // by canonical email
+	for id, user := range existing {
+		byEmail[canonicalEmail(user.Email)] = id
+	}
+	loaded := make(map[UserID]*User, len(users))
+	maxID := 0
+	for i, user := range users {
+		if user == nil {
+			return nil, 0, fmt.Errorf("user %d is null", i)
+		}
+		if id, err := parseUserID(string(user.ID)); err != nil || id != user.ID {
+			return nil, 0, fmt.Errorf("user %d has invalid id %s", i, user.ID)
+		}
+		if _, exists := loaded[user.ID]; exists {
+			return nil, 0, fmt.Errorf("duplicate id %s", user.ID)
+		}
+		if _, exists := existing[user.ID]; exists {
+			return nil, 0, fmt.Errorf("id %s is already in the store", user.ID)
+		}
+		user.Email = strings.TrimSpace(user.Email)
+		if other, exists := byEmail[canonicalEmail(user.Email)]; exists {
+			return nil, 0, fmt.Errorf("duplicate email %q for ids %s and %s", user.Email, other, user.ID)
+		}
+		if user.Version < 1 {
+			user.Version = 1
+		}
+		loaded[user.ID] = user
+		byEmail[canonicalEmail(user.Email)] = user.ID
+		if n, ok := user.ID.sequence(); ok {
+			maxID = max(maxID, n)
+		}
+	}
+	return loaded, maxID, nil
+}
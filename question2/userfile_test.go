@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeUsersFile writes content to a users file in a temporary directory
+func writeUsersFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "users.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestNewUserStoreFromFile tests loading a valid file and that new users get IDs after the loaded ones
+func TestNewUserStoreFromFile(t *testing.T) {
+	path := writeUsersFile(t, `[
+		{"id": 3, "name": "John Doe", "email": "john@example.com", "version": 2},
+		{"id": 7, "name": "Jane Doe", "email": "jane@example.com"}
+	]`)
+
+	store, err := NewUserStoreFromFile(path)
+	if err != nil {
+		t.Fatalf("expected the file to load, got %v", err)
+	}
+	if user, exists := store.Get("3"); !exists || user.Name != "John Doe" || user.Version != 2 {
+		t.Errorf("unexpected user 3: %+v", user)
+	}
+	if user, _ := store.Get("7"); user.Version != 1 {
+		t.Errorf("expected a missing version to default to 1, got %d", user.Version)
+	}
+
+	created, err := store.Create("New User", "new@example.com", "")
+	if err != nil {
+		t.Fatalf("create after load: %v", err)
+	}
+	if created.ID != "8" {
+		t.Errorf("expected the next ID to be 8, got %s", created.ID)
+	}
+}
+
+// TestNewUserStoreFromFile_Invalid tests that files breaking the uniqueness invariants are rejected
+func TestNewUserStoreFromFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			"duplicate id",
+			`[{"id": 1, "name": "John", "email": "john@example.com"}, {"id": 1, "name": "Jane", "email": "jane@example.com"}]`,
+			"duplicate id 1",
+		},
+		{
+			"duplicate email",
+			`[{"id": 1, "name": "John", "email": "john@example.com"}, {"id": 2, "name": "Johnny", "email": "john@example.com"}]`,
+			`duplicate email "john@example.com" for ids 1 and 2`,
+		},
+		{"invalid id", `[{"id": 0, "name": "John", "email": "john@example.com"}]`, "invalid id 0"},
+		{"not json", `{"id": 1`, "decode users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewUserStoreFromFile(writeUsersFile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if _, err := NewUserStoreFromFile(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("expected a not-exist error for a missing file, got %v", err)
+	}
+}
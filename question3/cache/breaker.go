@@ -0,0 +1,121 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned by GetOrCompute and GetOrComputeMany while the loader breaker is open,
+// wrapped together with the loader error that opened it
+var ErrBreakerOpen = errors.New("cache: loader circuit breaker is open")
+
+// BreakerOptions configures the circuit breaker around GetOrCompute and GetOrComputeMany loaders.
+// After Threshold consecutive loader errors the breaker opens and misses fail fast without calling
+// the loader for Cooldown. Then one call is let through as a trial: success closes the breaker,
+// failure opens it for another Cooldown
+type BreakerOptions struct {
+	// Threshold is how many consecutive loader errors open the breaker, zero disables it
+	Threshold int
+	// Window limits how far apart the counted errors may be, an error more than Window after
+	// the first one of the run starts a new run. Zero counts errors regardless of their age
+	Window time.Duration
+	// Cooldown is how long the breaker stays open before the trial call
+	Cooldown time.Duration
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen // cooldown is over and the trial call is running
+)
+
+// circuitBreaker tracks loader failures, a nil breaker always allows calls
+type circuitBreaker struct {
+	opts BreakerOptions
+
+	mu           sync.Mutex
+	state        breakerState
+	failures     int       // consecutive errors in the current run
+	firstFailure time.Time // when the current run started, for Window
+	openedAt     time.Time
+	lastErr      error
+}
+
+// newCircuitBreaker returns nil when the options disable the breaker
+func newCircuitBreaker(opts BreakerOptions) *circuitBreaker {
+	if opts.Threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{opts: opts}
+}
+
+// allow reports whether the loader may be called now. It returns an error wrapping both
+// ErrBreakerOpen and the last loader error while open or while another trial is running.
+// A nil error must be followed by exactly one record call
+func (b *circuitBreaker) allow(now time.Time) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if now.Sub(b.openedAt) >= b.opts.Cooldown {
+			b.state = breakerHalfOpen
+			return nil
+		}
+	case breakerClosed:
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrBreakerOpen, b.lastErr)
+}
+
+// record updates the breaker with the outcome of a loader call that allow let through
+func (b *circuitBreaker) record(err error, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.state = breakerClosed
+		b.failures = 0
+		return
+	}
+
+	b.lastErr = err
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+		b.openedAt = now
+		return
+	}
+	if b.failures == 0 || (b.opts.Window > 0 && now.Sub(b.firstFailure) > b.opts.Window) {
+		b.failures = 0
+		b.firstFailure = now
+	}
+	b.failures++
+	if b.failures >= b.opts.Threshold {
+		b.state = breakerOpen
+		b.openedAt = now
+		b.failures = 0
+	}
+}
+
+// stale returns the value of an expired entry that cleanup hasn't removed yet,
+// which is served instead of failing while the breaker is open
+func (c *TTLCache) stale(key string) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	return item.value, true
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTTLCache_Breaker tests that the loader stops being called while the breaker is open
+// and that a successful trial after the cooldown closes it again
+func TestTTLCache_Breaker(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL: time.Minute,
+		Breaker:    BreakerOptions{Threshold: 3, Cooldown: 50 * time.Millisecond},
+	})
+	defer cache.Stop()
+
+	errDown := errors.New("downstream unavailable")
+	calls := 0
+	failing := func() (interface{}, error) {
+		calls++
+		return nil, errDown
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.GetOrCompute("key", failing); !errors.Is(err, errDown) || errors.Is(err, ErrBreakerOpen) {
+			t.Fatalf("call %d: expected the loader error, got %v", i, err)
+		}
+	}
+
+	// open: the loader is skipped and the error says why
+	for i := 0; i < 5; i++ {
+		_, err := cache.GetOrCompute("other", failing)
+		if !errors.Is(err, ErrBreakerOpen) || !errors.Is(err, errDown) {
+			t.Fatalf("expected ErrBreakerOpen wrapping the last error, got %v", err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected the loader to stop at 3 calls while open, got %d", calls)
+	}
+
+	// after the cooldown a failing trial opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	cache.GetOrCompute("key", failing)
+	cache.GetOrCompute("key", failing)
+	if calls != 4 {
+		t.Errorf("expected a single trial call, got %d calls", calls)
+	}
+
+	// a successful trial closes it
+	time.Sleep(60 * time.Millisecond)
+	value, err := cache.GetOrCompute("key", func() (interface{}, error) { return "recovered", nil })
+	if err != nil || value != "recovered" {
+		t.Fatalf("expected the trial to succeed, got %v, %v", value, err)
+	}
+	if _, err := cache.GetOrCompute("next", failing); !errors.Is(err, errDown) || errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("expected the closed breaker to call the loader, got %v", err)
+	}
+}
+
+// TestTTLCache_BreakerLoaderPanic tests that panics count as loader failures and that a trial
+// panicking after the cooldown reopens the breaker instead of leaving it half-open for good
+func TestTTLCache_BreakerLoaderPanic(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL: time.Minute,
+		Breaker:    BreakerOptions{Threshold: 2, Cooldown: 50 * time.Millisecond},
+	})
+	defer cache.Stop()
+
+	panicking := func() (interface{}, error) { panic("boom") }
+	for i := 0; i < 2; i++ {
+		if _, err := cache.GetOrCompute("key", panicking); !errors.Is(err, ErrLoaderPanic) {
+			t.Fatalf("call %d: expected ErrLoaderPanic, got %v", i, err)
+		}
+	}
+	if _, err := cache.GetOrCompute("key", panicking); !errors.Is(err, ErrBreakerOpen) || !errors.Is(err, ErrLoaderPanic) {
+		t.Fatalf("expected the panics to open the breaker, got %v", err)
+	}
+
+	// the trial panics too, which must open the breaker again for another cooldown
+	time.Sleep(60 * time.Millisecond)
+	if _, err := cache.GetOrCompute("key", panicking); !errors.Is(err, ErrLoaderPanic) || errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("expected the trial to run and panic, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	value, err := cache.GetOrCompute("key", func() (interface{}, error) { return "recovered", nil })
+	if err != nil || value != "recovered" {
+		t.Fatalf("expected a successful trial after the panicking one, got %v, %v", value, err)
+	}
+	values, err := cache.GetOrComputeMany([]string{"other"}, func(missing []string) (map[string]interface{}, error) {
+		return map[string]interface{}{"other": 1}, nil
+	})
+	if err != nil || values["other"] != 1 {
+		t.Errorf("expected the closed breaker to call the loader, got %v, %v", values, err)
+	}
+}
+
+// TestTTLCache_BreakerServesStale tests that an open breaker serves expired values still in memory
+func TestTTLCache_BreakerServesStale(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL: time.Minute,
+		Breaker:    BreakerOptions{Threshold: 1, Cooldown: time.Minute},
+	})
+	defer cache.Stop()
+
+	cache.SetWithTTL("key", "stale", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	errDown := errors.New("downstream unavailable")
+	if _, err := cache.GetOrCompute("key", func() (interface{}, error) { return nil, errDown }); !errors.Is(err, errDown) {
+		t.Fatalf("expected the loader error to open the breaker, got %v", err)
+	}
+
+	value, err := cache.GetOrCompute("key", func() (interface{}, error) {
+		t.Error("the loader must not run while open")
+		return nil, nil
+	})
+	if err != nil || value != "stale" {
+		t.Errorf("expected the stale value, got %v, %v", value, err)
+	}
+
+	values, err := cache.GetOrComputeMany([]string{"key", "missing"}, func(missing []string) (map[string]interface{}, error) {
+		t.Error("the loader must not run while open")
+		return nil, nil
+	})
+	if !errors.Is(err, ErrBreakerOpen) || values["key"] != "stale" {
+		t.Errorf("expected the stale value with ErrBreakerOpen for the missing key, got %v, %v", values, err)
+	}
+}
+
+// TestCircuitBreaker_Window tests that errors further apart than the window don't open the breaker
+func TestCircuitBreaker_Window(t *testing.T) {
+	breaker := newCircuitBreaker(BreakerOptions{Threshold: 2, Window: time.Second, Cooldown: time.Minute})
+	errDown := errors.New("down")
+	start := time.Now()
+
+	breaker.record(errDown, start)
+	breaker.record(errDown, start.Add(2*time.Second))
+	if err := breaker.allow(start.Add(2 * time.Second)); err != nil {
+		t.Fatalf("errors outside the window must not open the breaker, got %v", err)
+	}
+
+	breaker.record(errDown, start.Add(2500*time.Millisecond))
+	if err := breaker.allow(start.Add(3 * time.Second)); !errors.Is(err, ErrBreakerOpen) {
+		t.Errorf("expected two errors within the window to open the breaker, got %v", err)
+	}
+}
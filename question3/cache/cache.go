@@ -1,28 +1,37 @@
 package cache
 
 import (
-	"log"
+	"container/heap"
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Cache defines the interface for cache operations
 type Cache interface {
 	Set(key string, value interface{})
+	// Get reports a miss only through the bool, a stored nil is returned as (nil, true)
 	Get(key string) (interface{}, bool)
 	Delete(key string)
 }
 
 // SimpleCache is a basic in-memory cache implementation
 type SimpleCache struct {
-	data map[string]interface{} //tipe map[string]interface{} adalah dictionary/hashmap
-	mu   sync.RWMutex
+	data   map[string]*simpleItem //tipe map[string]*simpleItem adalah dictionary/hashmap
+	mu     sync.RWMutex
+	closed bool // set by Close, writes are ignored or fail with ErrCacheClosed afterwards
 }
 
 // NewSimpleCache creates a new SimpleCache instance
 func NewSimpleCache() *SimpleCache {
 	return &SimpleCache{
-		data: make(map[string]interface{}),
+		data: make(map[string]*simpleItem),
 	}
 }
 
@@ -30,15 +39,139 @@ func NewSimpleCache() *SimpleCache {
 func (c *SimpleCache) Set(key string, value interface{}) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data[key] = value
+
+	if c.closed {
+		return
+	}
+	c.data[key] = newSimpleItem(value, time.Now())
+}
+
+// SetNX stores a value only if the key is absent, returning true when it was stored.
+// The check and the insert happen under the same lock so only one caller can win
+func (c *SimpleCache) SetNX(key string, value interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	if _, exists := c.data[key]; exists {
+		return false
+	}
+	c.data[key] = newSimpleItem(value, time.Now())
+	return true
+}
+
+// CompareAndSwap sets key to new only if its current value equals old (reflect.DeepEqual).
+// It returns ErrKeyNotFound for a missing key and ErrValueMismatch when the value differs
+func (c *SimpleCache) CompareAndSwap(key string, old, new interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrCacheClosed
+	}
+
+	current, exists := c.data[key]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	if !reflect.DeepEqual(current.value, old) {
+		return fmt.Errorf("%w: %s", ErrValueMismatch, key)
+	}
+	current.value = new
+	return nil
 }
 
 // Get retrieves a value from the cache
 func (c *SimpleCache) Get(key string) (interface{}, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	value, exists := c.data[key]
-	return value, exists
+	item, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	item.touch(time.Now())
+	return item.value, true
+}
+
+// GetContext is Get that first checks ctx, returning ctx.Err() if it is already done.
+// It is the common signature for cache tiers that may later be backed by I/O
+func (c *SimpleCache) GetContext(ctx context.Context, key string) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if c.isClosed() {
+		return nil, false, ErrCacheClosed
+	}
+	value, exists := c.Get(key)
+	return value, exists, nil
+}
+
+// GetMany retrieves several values under a single lock, missing keys are omitted from the result
+func (c *SimpleCache) GetMany(keys []string) map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	result := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if item, exists := c.data[key]; exists {
+			item.touch(now)
+			result[key] = item.value
+		}
+	}
+	return result
+}
+
+// SetMany stores several values under a single lock
+func (c *SimpleCache) SetMany(items map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+	now := time.Now()
+	for key, value := range items {
+		c.data[key] = newSimpleItem(value, now)
+	}
+}
+
+// Range calls fn for every entry until fn returns false.
+// fn runs while the read lock is held, so it must not call back into the cache or it will deadlock
+func (c *SimpleCache) Range(fn func(key string, value interface{}) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for key, item := range c.data {
+		if !fn(key, item.value) {
+			return
+		}
+	}
+}
+
+// Keys returns all keys currently in the cache
+func (c *SimpleCache) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.data))
+	for key := range c.data {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// WithPrefix returns a view of this cache that namespaces every key with prefix + ":"
+func (c *SimpleCache) WithPrefix(prefix string) *PrefixedCache {
+	return newPrefixedCache(c, prefix)
+}
+
+// WithKeyFunc returns a view of this cache that takes composite keys and stores them under
+// keyFunc(key), nil uses DefaultKeyFunc
+func (c *SimpleCache) WithKeyFunc(keyFunc KeyFunc) *KeyedCache {
+	return newKeyedCache(c, keyFunc)
 }
 
 // Delete removes a value from the cache
@@ -48,48 +181,236 @@ func (c *SimpleCache) Delete(key string) {
 	delete(c.data, key)
 }
 
+// DeleteMany removes several keys under a single lock and returns how many existed
+func (c *SimpleCache) DeleteMany(keys []string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for _, key := range keys {
+		if _, exists := c.data[key]; exists {
+			delete(c.data, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// DeleteByPrefix removes every key starting with prefix under a single lock and returns how many
+// were removed. An empty prefix clears the cache
+func (c *SimpleCache) DeleteByPrefix(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.data {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.data, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Increment atomically adds delta to the int64 stored at key and returns the new value.
+// A missing key starts from 0, a value that is not an int64 returns ErrTypeMismatch
+func (c *SimpleCache) Increment(key string, delta int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, ErrCacheClosed
+	}
+
+	item, exists := c.data[key]
+	if !exists {
+		c.data[key] = newSimpleItem(delta, time.Now())
+		return delta, nil
+	}
+
+	current, ok := item.value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("%w: value for key %s is %T, not int64", ErrTypeMismatch, key, item.value)
+	}
+
+	current += delta
+	item.value = current
+	return current, nil
+}
+
+// Decrement atomically subtracts delta from the int64 stored at key
+func (c *SimpleCache) Decrement(key string, delta int64) (int64, error) {
+	return c.Increment(key, -delta)
+}
+
 // cacheItem represents an item in the TTL cache with expiration time
 type cacheItem struct {
+	entryStats
 	value      interface{}
 	expiration time.Time
+	ttl        time.Duration // lifetime the item was stored with, used to push expiration forward in sliding mode
+	key        string        // set by put, lets cleanup find the map entry from the heap
+	index      int           // position in the expiration heap
 }
 
 // TTLCache is a cache implementation with time-to-live functionality
 type TTLCache struct {
 	data          map[string]*cacheItem // ← Shared data!
+	expirations   expirationHeap        // the items of data by expiration, guarded by mu
 	mu            sync.RWMutex
 	defaultTTL    time.Duration
 	cleanupTicker *time.Ticker
 	stopCleanup   chan bool
+	stopOnce      sync.Once // Stop may be called from several code paths
 	wg            sync.WaitGroup
+	sliding       bool // when true, every successful Get resets the item's expiration
+	jitter        float64
+	rng           *rand.Rand // source for jitter, guarded by mu since rand.Rand isn't safe for concurrent use
+	logger        *slog.Logger
+	getLatency    *latencyHistogram // nil unless latency recording is enabled
+	loadLatency   *latencyHistogram // GetOrCompute loader calls, nil unless latency recording is enabled
+	onEvict       EvictFunc
+	snapshotPath  string      // loaded by the constructor and written by Stop when set
+	computes      flightGroup // dedupes concurrent GetOrCompute misses per key
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64   // every entry removed, the same ones OnEvict is called for
+	breaker       *circuitBreaker // guards GetOrCompute loaders, nil when disabled
+	cleanupBatch  int             // expired entries removed per write lock, 0 removes all at once
+	evictOnClose  bool            // Close reports the remaining entries to onEvict
+	closed        bool            // set by Close, guarded by mu
 }
 
-// NewTTLCache creates a new TTLCache instance with specified default TTL
+// TTLCacheOptions configures a TTLCache created with NewTTLCacheWithOptions
+type TTLCacheOptions struct {
+	// DefaultTTL is the lifetime used by SetWithDefaultTTL
+	DefaultTTL time.Duration
+	// CleanupInterval is how often the background goroutine removes expired entries.
+	// Zero disables the goroutine: expired entries are still hidden by Get (lazy expiry)
+	// but stay in memory until overwritten or deleted, and Stop returns immediately
+	CleanupInterval time.Duration
+	// Sliding makes every successful Get reset the item's expiration
+	Sliding bool
+	// JitterFraction spreads expirations of new entries randomly by up to ±fraction of their TTL,
+	// so keys stored together don't all expire at once. Zero disables jitter
+	JitterFraction float64
+	// Logger receives the cache's logs, per-operation lines are at Debug level.
+	// Nil discards everything
+	Logger *slog.Logger
+	// OnEvict is called for every entry removed by cleanup, Delete, DeleteMany, DeleteByPrefix
+	// or Clear, with the reason. Entries that had already expired are reported as EvictExpired
+	OnEvict EvictFunc
+	// RecordLatency records Get durations, and separately the loader calls of GetOrCompute and
+	// GetOrComputeMany, into histograms exposed by Stats. When false the only cost on Get is a nil check
+	RecordLatency bool
+	// Context stops the cleanup goroutine when cancelled, as an alternative to Stop.
+	// Nil means the goroutine only stops on Stop
+	Context context.Context
+	// SnapshotPath makes the cache durable across restarts: the constructor loads the snapshot
+	// at this path if it exists, and Stop writes a new one after the cleanup goroutine has exited.
+	// Values must be gob-encodable, see SaveSnapshot. Empty disables persistence
+	SnapshotPath string
+	// CleanupBatchSize bounds how many expired entries cleanup removes per write lock. Between
+	// batches the lock is released so blocked Gets can run, trading a longer cleanup for shorter
+	// read stalls on large caches. Zero removes all expired entries under one lock
+	CleanupBatchSize int
+	// Breaker stops calling GetOrCompute and GetOrComputeMany loaders for a while after they keep
+	// failing, the zero value disables it
+	Breaker BreakerOptions
+	// EvictOnClose makes Close call OnEvict with EvictClosed for every entry still in the cache
+	EvictOnClose bool
+}
+
+// NewTTLCache creates a new TTLCache instance with specified default TTL.
+// Call Stop when done: the cleanup goroutine keeps the cache reachable, so it is never
+// garbage-collected (and no finalizer could fire) while the goroutine runs
 func NewTTLCache(defaultTTL time.Duration) *TTLCache {
+	return NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:      defaultTTL,
+		CleanupInterval: defaultCleanupInterval(defaultTTL),
+	})
+}
+
+// NewTTLCacheSliding creates a TTLCache with sliding expiration: every successful Get
+// extends the item by its TTL, so active keys stay alive and only idle keys expire
+func NewTTLCacheSliding(defaultTTL time.Duration) *TTLCache {
+	return NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:      defaultTTL,
+		CleanupInterval: defaultCleanupInterval(defaultTTL),
+		Sliding:         true,
+	})
+}
+
+// NewTTLCacheWithContext creates a TTLCache whose cleanup goroutine also exits when ctx is cancelled,
+// so a cache owned by a cancelled component doesn't leak it even without an explicit Stop
+func NewTTLCacheWithContext(ctx context.Context, defaultTTL time.Duration) *TTLCache {
+	return NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:      defaultTTL,
+		CleanupInterval: defaultCleanupInterval(defaultTTL),
+		Context:         ctx,
+	})
+}
+
+// NewTTLCacheWithOptions creates a TTLCache with an explicit cleanup interval.
+// Use NewTTLCache to keep the interval derived from the default TTL
+func NewTTLCacheWithOptions(opts TTLCacheOptions) *TTLCache {
 	cache := &TTLCache{
-		data:        make(map[string]*cacheItem),
-		defaultTTL:  defaultTTL,
-		stopCleanup: make(chan bool),
+		data:         make(map[string]*cacheItem),
+		defaultTTL:   opts.DefaultTTL,
+		stopCleanup:  make(chan bool),
+		sliding:      opts.Sliding,
+		jitter:       opts.JitterFraction,
+		logger:       opts.Logger,
+		onEvict:      opts.OnEvict,
+		snapshotPath: opts.SnapshotPath,
+		breaker:      newCircuitBreaker(opts.Breaker),
+		cleanupBatch: opts.CleanupBatchSize,
+		evictOnClose: opts.EvictOnClose,
+	}
+	if cache.logger == nil {
+		cache.logger = discardLogger
+	}
+	if opts.RecordLatency {
+		cache.getLatency = newLatencyHistogram()
+		cache.loadLatency = newLatencyHistogram()
+	}
+	if cache.jitter > 0 {
+		cache.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if cache.snapshotPath != "" {
+		if err := cache.loadSnapshotFile(cache.snapshotPath); err != nil {
+			cache.logger.Warn("cache snapshot not loaded", "path", cache.snapshotPath, "error", err)
+		}
 	}
 
-	// Start a background cleanup goroutine
-	cache.startCleanup()
+	// Start a background cleanup goroutine, unless it was disabled
+	if opts.CleanupInterval > 0 {
+		ctx := opts.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		cache.startCleanup(ctx, opts.CleanupInterval)
+	}
 
 	return cache
 }
 
-// startCleanup starts a background goroutine to periodically clean expired entries
-func (c *TTLCache) startCleanup() {
+// defaultCleanupInterval derives the cleanup interval from the default TTL
+func defaultCleanupInterval(defaultTTL time.Duration) time.Duration {
 	// Run cleanup every minute or every TTL/2, whichever is shorter
 	//this is primary logic to determine cleanup interval, dibagi 2 adalah agar memiliki interval yang lebih ideal
-	cleanupInterval := c.defaultTTL / 2
+	cleanupInterval := defaultTTL / 2
 	if cleanupInterval > time.Minute {
 		cleanupInterval = time.Minute // Max: 1 minute
 	}
 	if cleanupInterval < time.Second {
 		cleanupInterval = time.Second // Min: 1 second
 	}
+	return cleanupInterval
+}
 
+// startCleanup starts a background goroutine to periodically clean expired entries
+func (c *TTLCache) startCleanup(ctx context.Context, cleanupInterval time.Duration) {
 	//start ticker, check for expired items every cleanupInterval, seperti setInterval() di js
 	c.cleanupTicker = time.NewTicker(cleanupInterval)
 	c.wg.Add(1)
@@ -99,10 +420,14 @@ func (c *TTLCache) startCleanup() {
 		for {
 			select {
 			case <-c.cleanupTicker.C:
-				log.Printf("cleanup called, checking expired items every %v", cleanupInterval)
+				c.logger.Debug("cache cleanup", "interval", cleanupInterval)
 				c.deleteExpired()
 			case <-c.stopCleanup: //stop the loop
-				log.Println("cleanup stopped")
+				c.logger.Debug("cache cleanup stopped")
+				return
+			case <-ctx.Done(): // owner cancelled without calling Stop
+				c.cleanupTicker.Stop()
+				c.logger.Debug("cache cleanup stopped, context done")
 				return
 			}
 		}
@@ -111,38 +436,149 @@ func (c *TTLCache) startCleanup() {
 
 // deleteExpired removes all expired entries from the cache
 func (c *TTLCache) deleteExpired() {
+	for !c.deleteExpiredBatch() {
+	}
+}
+
+// deleteExpiredBatch removes up to cleanupBatch expired entries, or all of them when it is zero,
+// and reports whether none are left. The write lock is released between batches so Get calls
+// waiting for it run before the next batch
+func (c *TTLCache) deleteExpiredBatch() bool {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }() // deferred first so it runs after Unlock
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// the heap holds the soonest expiration first, so only expired entries are visited
 	now := time.Now()
-	//check if any item has expired > now
-	for key, item := range c.data {
-		if now.After(item.expiration) {
-			log.Printf("delete expired item %s", key)
-			delete(c.data, key)
+	for removed := 0; c.cleanupBatch <= 0 || removed < c.cleanupBatch; removed++ {
+		if len(c.expirations) == 0 || !now.After(c.expirations[0].expiration) {
+			return true
 		}
+		item := c.expirations[0]
+		c.logger.Debug("cache delete expired", "key", item.key)
+		c.evict(&evicted, item.key, item, now, EvictExpired)
 	}
+	return false
 }
 
-// Set stores a value in the cache with default TTL
+// evict removes key and records it for the OnEvict callback. An entry that already expired
+// is reported as EvictExpired whatever removed it. The caller must hold the write lock
+func (c *TTLCache) evict(evicted *[]eviction, key string, item *cacheItem, now time.Time, reason EvictReason) {
+	delete(c.data, key)
+	heap.Remove(&c.expirations, item.index)
+	c.evictions.Add(1)
+	if c.onEvict == nil {
+		return
+	}
+	if now.After(item.expiration) {
+		reason = EvictExpired
+	}
+	*evicted = append(*evicted, eviction{key: key, value: item.value, reason: reason})
+}
+
+// Set stores a value in the cache with default TTL, so TTLCache satisfies the Cache interface
+func (c *TTLCache) Set(key string, value interface{}) {
+	c.SetWithTTL(key, value, c.defaultTTL)
+}
+
+// SetWithDefaultTTL stores a value in the cache with default TTL
 func (c *TTLCache) SetWithDefaultTTL(key string, value interface{}) {
 	c.SetWithTTL(key, value, c.defaultTTL)
 }
 
+// expiresAt returns the expiration for an entry stored at now with ttl, applying the configured jitter.
+// The caller must hold the write lock
+func (c *TTLCache) expiresAt(now time.Time, ttl time.Duration) time.Time {
+	if c.jitter <= 0 {
+		return now.Add(ttl)
+	}
+	offset := (c.rng.Float64()*2 - 1) * c.jitter * float64(ttl)
+	return now.Add(ttl + time.Duration(offset))
+}
+
 // SetWithTTL stores a value in the cache with custom TTL
 func (c *TTLCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.data[key] = &cacheItem{
+	if c.closed {
+		return
+	}
+
+	now := time.Now()
+	c.put(key, &cacheItem{
+		entryStats: entryStats{createdAt: now},
+		value:      value,
+		expiration: c.expiresAt(now, ttl),
+		ttl:        ttl,
+	})
+	c.logger.Debug("cache set", "key", key, "ttl", ttl)
+}
+
+// SetNX stores a value with the default TTL only if the key is absent or expired
+func (c *TTLCache) SetNX(key string, value interface{}) bool {
+	return c.SetNXWithTTL(key, value, c.defaultTTL)
+}
+
+// SetNXWithTTL stores a value with a custom TTL only if the key is absent or expired,
+// returning false when the key already holds a live value
+func (c *TTLCache) SetNXWithTTL(key string, value interface{}, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	now := time.Now()
+	if item, exists := c.data[key]; exists && !now.After(item.expiration) {
+		return false
+	}
+
+	c.put(key, &cacheItem{
+		entryStats: entryStats{createdAt: now},
 		value:      value,
-		expiration: time.Now().Add(ttl),
+		expiration: c.expiresAt(now, ttl),
+		ttl:        ttl,
+	})
+	c.logger.Debug("cache setnx", "key", key, "ttl", ttl)
+	return true
+}
+
+// CompareAndSwap sets key to new only if it holds a live value equal to old (reflect.DeepEqual).
+// It returns ErrKeyNotFound for a missing or expired key and ErrValueMismatch when the value
+// differs. The entry keeps its expiration
+func (c *TTLCache) CompareAndSwap(key string, old, new interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrCacheClosed
 	}
-	log.Printf("Set %s to %v with TTL %v", key, value, ttl)
+
+	item, exists := c.data[key]
+	if !exists || time.Now().After(item.expiration) {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+	}
+	if !reflect.DeepEqual(item.value, old) {
+		return fmt.Errorf("%w: %s", ErrValueMismatch, key)
+	}
+	item.value = new
+	c.logger.Debug("cache compare and swap", "key", key)
+	return nil
 }
 
 // Get retrieves a value from the cache if it exists and hasn't expired
-func (c *TTLCache) Get(key string) (interface{}, bool) {
+func (c *TTLCache) Get(key string) (value interface{}, exists bool) {
+	if c.getLatency != nil {
+		defer c.getLatency.observe(time.Now())
+	}
+	defer func() { c.countLookup(exists) }()
+	if c.sliding {
+		return c.getSliding(key)
+	}
+
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -153,32 +589,335 @@ func (c *TTLCache) Get(key string) (interface{}, bool) {
 
 	// Check if an item has expired, prevent returning expired items
 	// For memory-critical applications, consider (delete-on-get).
-	if time.Now().After(item.expiration) {
+	now := time.Now()
+	if now.After(item.expiration) {
 		return nil, false
 	}
 
-	log.Printf("Get %s from cache success", key)
+	item.touch(now)
+	c.logger.Debug("cache hit", "key", key)
 	return item.value, true
 }
 
+// Peek retrieves a live value without refreshing a sliding expiration or recording latency
+func (c *TTLCache) Peek(key string) (interface{}, bool) {
+	value, _, exists := c.GetWithTTL(key)
+	return value, exists
+}
+
+// GetContext is Get that first checks ctx, returning ctx.Err() if it is already done
+func (c *TTLCache) GetContext(ctx context.Context, key string) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if c.isClosed() {
+		return nil, false, ErrCacheClosed
+	}
+	value, exists := c.Get(key)
+	return value, exists, nil
+}
+
+// GetWithTTL returns a live value together with its remaining lifetime under one read lock,
+// e.g. to compute a Cache-Control max-age. A missing or expired key returns (nil, 0, false).
+// Like Range it is a peek, sliding expirations are not refreshed
+func (c *TTLCache) GetWithTTL(key string) (interface{}, time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.data[key]
+	if !exists {
+		return nil, 0, false
+	}
+	remaining := time.Until(item.expiration)
+	if remaining <= 0 {
+		return nil, 0, false
+	}
+	return item.value, remaining, true
+}
+
+// getSliding is Get for sliding mode, it needs the write lock because it moves the expiration forward
+func (c *TTLCache) getSliding(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+
+	now := time.Now()
+	if now.After(item.expiration) {
+		return nil, false
+	}
+
+	item.expiration = now.Add(item.ttl)
+	c.reschedule(item)
+	item.touch(now)
+	c.logger.Debug("cache hit, expiration extended", "key", key, "ttl", item.ttl)
+	return item.value, true
+}
+
+// GetMany retrieves several values under a single lock, missing and expired keys are omitted.
+// In sliding mode the returned items get their expiration extended like Get does
+func (c *TTLCache) GetMany(keys []string) map[string]interface{} {
+	lock, unlock := c.mu.RLock, c.mu.RUnlock
+	if c.sliding {
+		lock, unlock = c.mu.Lock, c.mu.Unlock
+	}
+	lock()
+	defer unlock()
+
+	now := time.Now()
+	result := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		item, exists := c.data[key]
+		if !exists || now.After(item.expiration) {
+			c.misses.Add(1)
+			continue
+		}
+		c.hits.Add(1)
+		item.touch(now)
+		if c.sliding {
+			item.expiration = now.Add(item.ttl)
+			c.reschedule(item)
+		}
+		result[key] = item.value
+	}
+	return result
+}
+
+// SetMany stores several values with the default TTL under a single lock
+func (c *TTLCache) SetMany(items map[string]interface{}) {
+	c.SetManyWithTTL(items, c.defaultTTL)
+}
+
+// SetManyWithTTL stores several values with a custom TTL under a single lock
+func (c *TTLCache) SetManyWithTTL(items map[string]interface{}, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	now := time.Now()
+	for key, value := range items {
+		c.put(key, &cacheItem{
+			entryStats: entryStats{createdAt: now},
+			value:      value,
+			expiration: c.expiresAt(now, ttl),
+			ttl:        ttl,
+		})
+	}
+	c.logger.Debug("cache set many", "count", len(items), "ttl", ttl)
+}
+
+// Range calls fn for every live entry until fn returns false. Expired entries are skipped
+// and sliding expirations are not refreshed, a scan is not an access.
+// fn runs while the read lock is held, so it must not call back into the cache or it will deadlock
+func (c *TTLCache) Range(fn func(key string, value interface{}) bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	for key, item := range c.data {
+		if now.After(item.expiration) {
+			continue
+		}
+		if !fn(key, item.value) {
+			return
+		}
+	}
+}
+
+// Keys returns the keys of all live entries
+func (c *TTLCache) Keys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(c.data))
+	for key, item := range c.data {
+		if !now.After(item.expiration) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// WithPrefix returns a view of this cache that namespaces every key with prefix + ":".
+// All views share this cache's storage and cleanup goroutine
+func (c *TTLCache) WithPrefix(prefix string) *PrefixedCache {
+	return newPrefixedCache(c, prefix)
+}
+
+// WithKeyFunc returns a view of this cache that takes composite keys and stores them under
+// keyFunc(key), nil uses DefaultKeyFunc. Entries get the default TTL
+func (c *TTLCache) WithKeyFunc(keyFunc KeyFunc) *KeyedCache {
+	return newKeyedCache(c, keyFunc)
+}
+
+// Touch extends a live item's expiration to now+ttl without re-supplying the value.
+// It returns false if the key does not exist or has already expired
+func (c *TTLCache) Touch(key string, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.data[key]
+	if !exists {
+		return false
+	}
+
+	now := time.Now()
+	if now.After(item.expiration) {
+		return false
+	}
+
+	item.expiration = now.Add(ttl)
+	item.ttl = ttl // keep sliding refreshes consistent with the new lifetime
+	c.reschedule(item)
+	c.logger.Debug("cache touch", "key", key, "ttl", ttl)
+	return true
+}
+
+// Increment atomically adds delta to the int64 stored at key and returns the new value.
+// An existing entry keeps its expiration, a missing or expired key starts from 0 with the default TTL.
+// A value that is not an int64 returns ErrTypeMismatch
+func (c *TTLCache) Increment(key string, delta int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, ErrCacheClosed
+	}
+
+	now := time.Now()
+	item, exists := c.data[key]
+	if !exists || now.After(item.expiration) {
+		c.put(key, &cacheItem{
+			entryStats: entryStats{createdAt: now},
+			value:      delta,
+			expiration: c.expiresAt(now, c.defaultTTL),
+			ttl:        c.defaultTTL,
+		})
+		return delta, nil
+	}
+
+	current, ok := item.value.(int64)
+	if !ok {
+		return 0, fmt.Errorf("%w: value for key %s is %T, not int64", ErrTypeMismatch, key, item.value)
+	}
+
+	current += delta
+	item.value = current
+	return current, nil
+}
+
+// Decrement atomically subtracts delta from the int64 stored at key
+func (c *TTLCache) Decrement(key string, delta int64) (int64, error) {
+	return c.Increment(key, -delta)
+}
+
 // Delete removes a value from the cache
 func (c *TTLCache) Delete(key string) {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	log.Printf("Delete %s from cache", key)
-	delete(c.data, key)
+	c.logger.Debug("cache delete", "key", key)
+	if item, exists := c.data[key]; exists {
+		c.evict(&evicted, key, item, time.Now(), EvictDeleted)
+	}
+}
+
+// DeleteMany removes several keys under a single lock and returns how many held a live value.
+// Expired entries among the keys are removed too but not counted
+func (c *TTLCache) DeleteMany(keys []string) int {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for _, key := range keys {
+		item, exists := c.data[key]
+		if !exists {
+			continue
+		}
+		if !now.After(item.expiration) {
+			removed++
+		}
+		c.evict(&evicted, key, item, now, EvictDeleted)
+	}
+	c.logger.Debug("cache delete many", "removed", removed, "requested", len(keys))
+	return removed
+}
+
+// DeleteByPrefix removes every key starting with prefix under a single lock and returns how many
+// held a live value. An empty prefix clears the cache
+func (c *TTLCache) DeleteByPrefix(prefix string) int {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for key, item := range c.data {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if !now.After(item.expiration) {
+			removed++
+		}
+		c.evict(&evicted, key, item, now, EvictDeleted)
+	}
+	c.logger.Debug("cache delete by prefix", "removed", removed, "prefix", prefix)
+	return removed
 }
 
-// Stop stops the background cleanup goroutine
+// Stop stops the background cleanup goroutine, then writes the snapshot when SnapshotPath is set.
+// It is safe to call more than once and on a cache created with cleanup disabled
 func (c *TTLCache) Stop() {
-	c.cleanupTicker.Stop() // Stop ticker first
-	close(c.stopCleanup)   // Close instead of send
-	c.wg.Wait()            // ← Wait for goroutine to finish
+	c.stopOnce.Do(func() {
+		if c.cleanupTicker != nil {
+			c.cleanupTicker.Stop() // Stop ticker first
+		}
+		close(c.stopCleanup) // Close instead of send, a second close would panic
+
+		// Flush only once the cleanup goroutine is gone, so it can't mutate the cache mid-snapshot
+		c.wg.Wait()
+		if c.snapshotPath != "" {
+			if err := c.saveSnapshotFile(c.snapshotPath); err != nil {
+				c.logger.Warn("cache snapshot not saved", "path", c.snapshotPath, "error", err)
+			}
+		}
+	})
+	c.wg.Wait() // ← Wait for goroutine to finish
 }
 
-// Clear removes all entries from the cache
+// Clear removes all entries from the cache, calling OnEvict with EvictCleared for each of them.
+// Cleared entries add to the Evictions counter while Hits and Misses are kept
 func (c *TTLCache) Clear() {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.data = make(map[string]*cacheItem)
+
+	now := time.Now()
+	for key, item := range c.data {
+		c.evict(&evicted, key, item, now, EvictCleared)
+	}
+	c.data = make(map[string]*cacheItem) // release the old buckets
+	c.expirations = nil
+}
+
+// countLookup records a Get or GetMany lookup in the hit and miss counters
+func (c *TTLCache) countLookup(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
 }
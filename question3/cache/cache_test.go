@@ -1,6 +1,15 @@
 package cache
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -33,3 +42,678 @@ func TestTTLCache_DeleteExpired(t *testing.T) {
 		t.Error("item3 should be deleted")
 	}
 }
+
+// TestTTLCache_SlidingExpiration tests that reads keep an item alive and idle items still expire
+func TestTTLCache_SlidingExpiration(t *testing.T) {
+	ttl := 100 * time.Millisecond
+	cache := NewTTLCacheSliding(ttl)
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("session", "active")
+
+	// Keep reading for well past the original TTL
+	for i := 0; i < 5; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if _, exists := cache.Get("session"); !exists {
+			t.Fatalf("session should still be alive after read %d", i+1)
+		}
+	}
+
+	// Stop reading, the item should expire after being idle for its TTL
+	time.Sleep(150 * time.Millisecond)
+	if _, exists := cache.Get("session"); exists {
+		t.Error("session should expire once it is idle")
+	}
+}
+
+// TestTTLCache_Touch tests extending a live item and touching an expired one
+func TestTTLCache_Touch(t *testing.T) {
+	cache := NewTTLCache(100 * time.Millisecond)
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("live", "value")
+	cache.SetWithTTL("expired", "value", 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	// Touching an expired key should fail
+	if cache.Touch("expired", time.Second) {
+		t.Error("touching an expired key should return false")
+	}
+	if cache.Touch("missing", time.Second) {
+		t.Error("touching a missing key should return false")
+	}
+
+	// Touching a live key should keep it past its original TTL
+	if !cache.Touch("live", time.Second) {
+		t.Fatal("touching a live key should return true")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, exists := cache.Get("live"); !exists {
+		t.Error("live should still exist after touch")
+	}
+}
+
+// TestSimpleCache_Increment tests counters starting from zero and rejecting non-int64 values
+func TestSimpleCache_Increment(t *testing.T) {
+	cache := NewSimpleCache()
+
+	if n, err := cache.Increment("counter", 5); err != nil || n != 5 {
+		t.Fatalf("expected 5, got %d (err %v)", n, err)
+	}
+	if n, err := cache.Decrement("counter", 2); err != nil || n != 3 {
+		t.Fatalf("expected 3, got %d (err %v)", n, err)
+	}
+
+	cache.Set("name", "John")
+	if _, err := cache.Increment("name", 1); err == nil {
+		t.Error("incrementing a non-int64 value should return an error")
+	}
+}
+
+// TestTTLCache_IncrementConcurrent tests that concurrent increments are not lost and keep the expiration
+func TestTTLCache_IncrementConcurrent(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetWithTTL("hits", int64(0), 100*time.Millisecond)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Increment("hits", 1); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if value, _ := cache.Get("hits"); value != int64(100) {
+		t.Errorf("expected 100, got %v", value)
+	}
+
+	// The original 100ms expiration must be preserved
+	time.Sleep(150 * time.Millisecond)
+	if _, exists := cache.Get("hits"); exists {
+		t.Error("hits should expire with its original TTL")
+	}
+}
+
+// TestSimpleCache_SetNXConcurrent tests that exactly one of many concurrent SetNX calls wins
+func TestSimpleCache_SetNXConcurrent(t *testing.T) {
+	cache := NewSimpleCache()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if cache.SetNX("lock", i) {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("expected exactly 1 winner, got %d", winners)
+	}
+}
+
+// TestTTLCache_SetNX tests SetNX against live and expired keys
+func TestTTLCache_SetNX(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if cache.SetNXWithTTL("lock", i, 50*time.Millisecond) {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("expected exactly 1 winner, got %d", winners)
+	}
+
+	// Once the holder expires the key can be acquired again
+	time.Sleep(100 * time.Millisecond)
+	if !cache.SetNX("lock", "next") {
+		t.Error("SetNX should succeed on an expired key")
+	}
+}
+
+// TestTTLCache_CleanupInterval tests an explicit interval and a disabled background cleanup
+func TestTTLCache_CleanupInterval(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:      10 * time.Millisecond,
+		CleanupInterval: 20 * time.Millisecond,
+	})
+	cache.SetWithDefaultTTL("item", "value")
+	time.Sleep(100 * time.Millisecond)
+
+	cache.mu.RLock()
+	remaining := len(cache.data)
+	cache.mu.RUnlock()
+	if remaining != 0 {
+		t.Errorf("background cleanup should have removed the item, %d left", remaining)
+	}
+	cache.Stop()
+
+	// Zero interval: no goroutine, expiry is only lazy and Stop must not block
+	lazy := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: 10 * time.Millisecond})
+	lazy.SetWithDefaultTTL("item", "value")
+	time.Sleep(30 * time.Millisecond)
+	if _, exists := lazy.Get("item"); exists {
+		t.Error("expired item should not be returned without background cleanup")
+	}
+	lazy.Stop()
+}
+
+// TestTTLCache_StopTwice tests that repeated Stop calls are no-ops
+func TestTTLCache_StopTwice(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Stop panicked: %v", r)
+		}
+	}()
+
+	cache := NewTTLCache(time.Second)
+	cache.Stop()
+	cache.Stop()
+
+	disabled := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Second})
+	disabled.Stop()
+	disabled.Stop()
+}
+
+// TestTTLCache_SnapshotRoundTrip tests saving live entries and loading them into a new cache
+func TestTTLCache_SnapshotRoundTrip(t *testing.T) {
+	source := NewTTLCache(time.Minute)
+	defer source.Stop()
+
+	source.SetWithDefaultTTL("name", "Alice")
+	source.SetWithDefaultTTL("age", 30)
+	source.SetWithTTL("expired", "gone", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := source.SaveSnapshot(&buf); err != nil {
+		t.Fatalf("SaveSnapshot failed: %v", err)
+	}
+
+	target := NewTTLCache(time.Minute)
+	defer target.Stop()
+	if err := target.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("LoadSnapshot failed: %v", err)
+	}
+
+	if value, _ := target.Get("name"); value != "Alice" {
+		t.Errorf("expected Alice, got %v", value)
+	}
+	if value, _ := target.Get("age"); value != 30 {
+		t.Errorf("expected 30, got %v", value)
+	}
+	if _, exists := target.data["expired"]; exists {
+		t.Error("expired entry should not be in the snapshot")
+	}
+}
+
+// TestTTLCache_SnapshotPath tests that Stop flushes to SnapshotPath and a new cache loads it
+func TestTTLCache_SnapshotPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.gob")
+	opts := TTLCacheOptions{DefaultTTL: time.Minute, CleanupInterval: 10 * time.Millisecond, SnapshotPath: path}
+
+	// A missing file just starts empty
+	source := NewTTLCacheWithOptions(opts)
+	source.SetWithDefaultTTL("name", "Alice")
+	source.SetWithTTL("short", "soon gone", 150*time.Millisecond)
+	source.Stop()
+	source.Stop() // a second Stop must not fail or rewrite anything
+
+	target := NewTTLCacheWithOptions(opts)
+	defer target.Stop()
+	if value, _ := target.Get("name"); value != "Alice" {
+		t.Errorf("expected Alice, got %v", value)
+	}
+	_, remaining, exists := target.GetWithTTL("short")
+	if !exists || remaining > 150*time.Millisecond {
+		t.Errorf("expected short to keep its remaining TTL, got %v %v", remaining, exists)
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	if _, exists := target.Get("short"); exists {
+		t.Error("short should have expired after its remaining TTL")
+	}
+}
+
+// TestSimpleCache_GetManySetMany tests bulk operations only return present keys
+func TestSimpleCache_GetManySetMany(t *testing.T) {
+	cache := NewSimpleCache()
+	cache.SetMany(map[string]interface{}{"a": 1, "b": 2})
+
+	result := cache.GetMany([]string{"a", "b", "missing"})
+	if len(result) != 2 || result["a"] != 1 || result["b"] != 2 {
+		t.Errorf("unexpected result: %v", result)
+	}
+}
+
+// TestTTLCache_GetManySetMany tests bulk operations skip expired keys
+func TestTTLCache_GetManySetMany(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetMany(map[string]interface{}{"a": 1, "b": 2})
+	cache.SetManyWithTTL(map[string]interface{}{"short": 3}, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	result := cache.GetMany([]string{"a", "b", "short", "missing"})
+	if len(result) != 2 {
+		t.Fatalf("expected 2 keys, got %v", result)
+	}
+	if _, exists := result["short"]; exists {
+		t.Error("expired key should be omitted")
+	}
+}
+
+// TestTTLCache_Range tests counting live entries and stopping early
+func TestTTLCache_Range(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetMany(map[string]interface{}{"a": 1, "b": 2, "c": 3})
+	cache.SetWithTTL("expired", 4, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	count := 0
+	cache.Range(func(key string, value interface{}) bool {
+		count++
+		return true
+	})
+	if count != 3 {
+		t.Errorf("expected 3 live entries, got %d", count)
+	}
+
+	visited := 0
+	cache.Range(func(key string, value interface{}) bool {
+		visited++
+		return false
+	})
+	if visited != 1 {
+		t.Errorf("Range should stop after fn returns false, visited %d", visited)
+	}
+}
+
+// TestSimpleCache_Range tests counting entries and stopping early
+func TestSimpleCache_Range(t *testing.T) {
+	cache := NewSimpleCache()
+	cache.SetMany(map[string]interface{}{"a": 1, "b": 2, "c": 3})
+
+	count := 0
+	cache.Range(func(key string, value interface{}) bool {
+		count++
+		return true
+	})
+	if count != 3 {
+		t.Errorf("expected 3 entries, got %d", count)
+	}
+
+	visited := 0
+	cache.Range(func(key string, value interface{}) bool {
+		visited++
+		return visited < 2
+	})
+	if visited != 2 {
+		t.Errorf("Range should stop after fn returns false, visited %d", visited)
+	}
+}
+
+// TestGetContext_Cancelled tests that a cancelled context is reported before the lookup
+func TestGetContext_Cancelled(t *testing.T) {
+	simple := NewSimpleCache()
+	simple.Set("key", "value")
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+	ttl.SetWithDefaultTTL("key", "value")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := simple.GetContext(ctx, "key"); !errors.Is(err, context.Canceled) {
+		t.Errorf("SimpleCache: expected context.Canceled, got %v", err)
+	}
+	if _, _, err := ttl.GetContext(ctx, "key"); !errors.Is(err, context.Canceled) {
+		t.Errorf("TTLCache: expected context.Canceled, got %v", err)
+	}
+
+	value, exists, err := ttl.GetContext(context.Background(), "key")
+	if err != nil || !exists || value != "value" {
+		t.Errorf("expected value with live context, got %v %v %v", value, exists, err)
+	}
+}
+
+// TestTTLCache_ContextCancel tests that cancelling the context stops the cleanup goroutine
+func TestTTLCache_ContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cache := NewTTLCacheWithContext(ctx, time.Second)
+	cache.SetWithDefaultTTL("key", "value")
+
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		cache.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cleanup goroutine did not exit after the context was cancelled")
+	}
+
+	// The cache stays usable and Stop is still safe
+	if value, _ := cache.Get("key"); value != "value" {
+		t.Errorf("expected value, got %v", value)
+	}
+	cache.Stop()
+}
+
+// cleanupGoroutines counts running TTLCache cleanup goroutines, ignoring unrelated ones
+func cleanupGoroutines() int {
+	buf := make([]byte, 1<<20)
+	n := runtime.Stack(buf, true)
+	return strings.Count(string(buf[:n]), "(*TTLCache).startCleanup.func")
+}
+
+// waitForCleanupGoroutines polls until the number of cleanup goroutines drops to want
+func waitForCleanupGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		got := cleanupGoroutines()
+		if got == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d cleanup goroutines, got %d", want, got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestTTLCache_NoGoroutineLeak tests that Stop and context cancellation leave no cleanup goroutines behind
+func TestTTLCache_NoGoroutineLeak(t *testing.T) {
+	before := cleanupGoroutines()
+
+	caches := make([]*TTLCache, 10)
+	for i := range caches {
+		caches[i] = NewTTLCache(time.Minute)
+	}
+	if got := cleanupGoroutines(); got != before+len(caches) {
+		t.Fatalf("expected %d cleanup goroutines, got %d", before+len(caches), got)
+	}
+	for _, c := range caches {
+		c.Stop()
+	}
+	waitForCleanupGoroutines(t, before)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for i := 0; i < 5; i++ {
+		NewTTLCacheWithContext(ctx, time.Minute)
+	}
+	cancel()
+	waitForCleanupGoroutines(t, before)
+}
+
+// TestTTLCache_Jitter tests that jittered expirations spread out within ±JitterFraction of the TTL
+func TestTTLCache_Jitter(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Minute, JitterFraction: 0.1})
+	defer cache.Stop()
+	cache.rng = rand.New(rand.NewSource(42))
+
+	before := time.Now()
+	for i := 0; i < 100; i++ {
+		cache.SetWithDefaultTTL(strconv.Itoa(i), i)
+	}
+	after := time.Now()
+
+	earliest := before.Add(54 * time.Second)
+	latest := after.Add(66 * time.Second)
+	distinct := make(map[time.Time]bool)
+	for key, item := range cache.data {
+		if item.expiration.Before(earliest) || item.expiration.After(latest) {
+			t.Errorf("%s: expiration %v outside [%v, %v]", key, item.expiration, earliest, latest)
+		}
+		distinct[item.expiration] = true
+	}
+	if len(distinct) < 90 {
+		t.Errorf("expected expirations to be spread out, got %d distinct values", len(distinct))
+	}
+}
+
+// TestDeleteByPrefix tests prefix matching, including the empty prefix clearing everything
+func TestDeleteByPrefix(t *testing.T) {
+	items := map[string]interface{}{"user:1": 1, "user:2": 2, "session:1": 3, "users": 4}
+
+	tests := []struct {
+		name    string
+		prefix  string
+		removed int
+		left    int
+	}{
+		{"prefix with separator", "user:", 2, 2},
+		{"prefix without separator", "user", 3, 1},
+		{"no match", "order:", 0, 4},
+		{"empty prefix clears all", "", 4, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			simple := NewSimpleCache()
+			simple.SetMany(items)
+			if removed := simple.DeleteByPrefix(tt.prefix); removed != tt.removed {
+				t.Errorf("SimpleCache: expected %d removed, got %d", tt.removed, removed)
+			}
+			if left := len(simple.Keys()); left != tt.left {
+				t.Errorf("SimpleCache: expected %d left, got %d", tt.left, left)
+			}
+
+			ttl := NewTTLCache(time.Minute)
+			defer ttl.Stop()
+			ttl.SetMany(items)
+			if removed := ttl.DeleteByPrefix(tt.prefix); removed != tt.removed {
+				t.Errorf("TTLCache: expected %d removed, got %d", tt.removed, removed)
+			}
+			if left := len(ttl.Keys()); left != tt.left {
+				t.Errorf("TTLCache: expected %d left, got %d", tt.left, left)
+			}
+		})
+	}
+}
+
+// TestDeleteMany tests that only existing, live keys are counted
+func TestDeleteMany(t *testing.T) {
+	simple := NewSimpleCache()
+	simple.SetMany(map[string]interface{}{"a": 1, "b": 2, "c": 3})
+	if removed := simple.DeleteMany([]string{"a", "b", "missing"}); removed != 2 {
+		t.Errorf("SimpleCache: expected 2 removed, got %d", removed)
+	}
+	if _, exists := simple.Get("c"); !exists {
+		t.Error("c should not be removed")
+	}
+
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+	ttl.SetMany(map[string]interface{}{"a": 1, "b": 2})
+	ttl.SetWithTTL("expired", 3, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if removed := ttl.DeleteMany([]string{"a", "expired", "missing"}); removed != 1 {
+		t.Errorf("TTLCache: expected 1 removed, got %d", removed)
+	}
+	if _, exists := ttl.data["expired"]; exists {
+		t.Error("expired entry should be removed as well")
+	}
+}
+
+// TestCompareAndSwap_Concurrent tests that only one of many competing CAS calls wins
+func TestCompareAndSwap_Concurrent(t *testing.T) {
+	simple := NewSimpleCache()
+	simple.Set("owner", "none")
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+	ttl.SetWithTTL("owner", "none", 100*time.Millisecond)
+
+	caches := map[string]interface {
+		CompareAndSwap(key string, old, new interface{}) error
+	}{"SimpleCache": simple, "TTLCache": ttl}
+
+	for name, c := range caches {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		winners := 0
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if c.CompareAndSwap("owner", "none", i) == nil {
+					mu.Lock()
+					winners++
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if winners != 1 {
+			t.Errorf("%s: expected exactly 1 winner, got %d", name, winners)
+		}
+	}
+
+	// Values are compared deeply and the TTL entry keeps its expiration
+	ttl.SetWithTTL("tags", []string{"a"}, 50*time.Millisecond)
+	if err := ttl.CompareAndSwap("tags", []string{"a"}, []string{"a", "b"}); err != nil {
+		t.Error("equal slices should match")
+	}
+	if err := simple.CompareAndSwap("missing", nil, 1); err == nil {
+		t.Error("a missing key should never match")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, exists := ttl.Get("tags"); exists {
+		t.Error("swapped entry should keep its original expiration")
+	}
+}
+
+// TestTTLCache_GetWithTTL tests that the remaining lifetime shrinks and expired keys report nothing
+func TestTTLCache_GetWithTTL(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetWithTTL("key", "value", 100*time.Millisecond)
+
+	value, first, exists := cache.GetWithTTL("key")
+	if !exists || value != "value" || first <= 0 || first > 100*time.Millisecond {
+		t.Fatalf("unexpected result: %v %v %v", value, first, exists)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	_, second, _ := cache.GetWithTTL("key")
+	if second >= first {
+		t.Errorf("remaining TTL should decrease, got %v then %v", first, second)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if value, remaining, exists := cache.GetWithTTL("key"); exists || value != nil || remaining != 0 {
+		t.Errorf("expected (nil, 0, false) for an expired key, got %v %v %v", value, remaining, exists)
+	}
+	if _, _, exists := cache.GetWithTTL("missing"); exists {
+		t.Error("missing key should not exist")
+	}
+}
+
+// TestTTLCache_Peek tests that Peek respects expiry and does not refresh a sliding TTL
+func TestTTLCache_Peek(t *testing.T) {
+	cache := NewTTLCacheSliding(60 * time.Millisecond)
+	defer cache.Stop()
+
+	cache.Set("key", "value")
+	time.Sleep(40 * time.Millisecond)
+	if value, exists := cache.Peek("key"); !exists || value != "value" {
+		t.Fatalf("expected Peek to return value, got %v, %v", value, exists)
+	}
+
+	// A Get at this point would have extended the lifetime, Peek must not
+	time.Sleep(40 * time.Millisecond)
+	if _, exists := cache.Peek("key"); exists {
+		t.Error("Peek should report the entry as expired")
+	}
+	if _, exists := cache.Peek("missing"); exists {
+		t.Error("Peek of a missing key should return false")
+	}
+}
+
+// TestNilValue tests that an explicit nil is a hit and only the bool reports a miss
+func TestNilValue(t *testing.T) {
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]Cache{
+		"SimpleCache":  NewSimpleCache(),
+		"TTLCache":     ttl,
+		"LRUCache":     NewLRUCache(10),
+		"LFUCache":     NewLFUCache(10),
+		"ShardedCache": NewShardedCache(4),
+		"SizedCache":   NewSizedCache(10, func(interface{}) int64 { return 1 }),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			c.Set("nil", nil)
+			if value, exists := c.Get("nil"); !exists || value != nil {
+				t.Errorf("expected (nil, true), got (%v, %v)", value, exists)
+			}
+			if value, exists := c.Get("missing"); exists || value != nil {
+				t.Errorf("expected (nil, false), got (%v, %v)", value, exists)
+			}
+		})
+	}
+
+	t.Run("TTLCache read paths", func(t *testing.T) {
+		if _, exists := ttl.GetMany([]string{"nil"})["nil"]; !exists {
+			t.Error("GetMany should include the nil value")
+		}
+		if _, _, exists := ttl.GetWithTTL("nil"); !exists {
+			t.Error("GetWithTTL should report the nil value as present")
+		}
+		if _, exists := ttl.Peek("nil"); !exists {
+			t.Error("Peek should report the nil value as present")
+		}
+	})
+
+	t.Run("JSON null", func(t *testing.T) {
+		cache := NewSimpleCache()
+		if err := cache.SetJSON("null", nil); err != nil {
+			t.Fatal(err)
+		}
+		var dest *string
+		if exists, err := cache.GetJSON("null", &dest); !exists || err != nil || dest != nil {
+			t.Errorf("expected a nil hit, got exists=%v err=%v dest=%v", exists, err, dest)
+		}
+		if exists, err := cache.GetJSON("missing", &dest); exists || err != nil {
+			t.Errorf("expected a miss, got exists=%v err=%v", exists, err)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"io"
+	"time"
+)
+
+var (
+	_ io.Closer = (*SimpleCache)(nil)
+	_ io.Closer = (*TTLCache)(nil)
+)
+
+// Close drops all entries and marks the cache closed. Afterwards reads miss, writes are ignored
+// and the methods returning an error return ErrCacheClosed. Closing twice is a no-op
+func (c *SimpleCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	c.data = make(map[string]*simpleItem)
+	return nil
+}
+
+// isClosed reports whether Close was called
+func (c *SimpleCache) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
+
+// Close stops the cache like Stop, including writing the snapshot, then drops all entries and
+// marks the cache closed. Afterwards reads miss, writes are ignored and the methods returning an
+// error return ErrCacheClosed. With TTLCacheOptions.EvictOnClose the remaining entries, expired
+// ones included, are reported to OnEvict with EvictClosed. Closing twice is a no-op
+func (c *TTLCache) Close() error {
+	c.Stop()
+
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.evictOnClose {
+		now := time.Now()
+		for key, item := range c.data {
+			c.evict(&evicted, key, item, now, EvictClosed)
+		}
+	}
+	c.data = make(map[string]*cacheItem)
+	c.expirations = nil
+	c.logger.Debug("cache closed", "evicted", len(evicted))
+	return nil
+}
+
+// isClosed reports whether Close was called
+func (c *TTLCache) isClosed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.closed
+}
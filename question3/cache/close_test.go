@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestClose tests that after Close reads miss, writes are ignored and error-returning
+// operations return ErrCacheClosed, for both caches
+func TestClose(t *testing.T) {
+	type closableCache interface {
+		Cache
+		Close() error
+		SetNX(key string, value interface{}) bool
+		Increment(key string, delta int64) (int64, error)
+		CompareAndSwap(key string, old, new interface{}) error
+		GetContext(ctx context.Context, key string) (interface{}, bool, error)
+		SetJSON(key string, v interface{}) error
+		GetJSON(key string, dest interface{}) (bool, error)
+		ExportJSON(w io.Writer) error
+		Append(key string, value interface{})
+		Keys() []string
+	}
+	caches := map[string]closableCache{
+		"SimpleCache": NewSimpleCache(),
+		"TTLCache":    NewTTLCache(time.Minute),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			c.Set("name", "John")
+			if err := c.Close(); err != nil {
+				t.Fatalf("Close failed: %v", err)
+			}
+			if err := c.Close(); err != nil {
+				t.Errorf("a second Close should be a no-op, got %v", err)
+			}
+
+			if _, exists := c.Get("name"); exists {
+				t.Error("entries should be dropped by Close")
+			}
+			c.Set("name", "Jane")
+			c.Append("list", 1)
+			if c.SetNX("other", 1) {
+				t.Error("SetNX should not store after Close")
+			}
+			if keys := c.Keys(); len(keys) != 0 {
+				t.Errorf("writes after Close should be ignored, got keys %v", keys)
+			}
+
+			tests := []struct {
+				name string
+				err  error
+			}{
+				{"Increment", func() error { _, err := c.Increment("counter", 1); return err }()},
+				{"CompareAndSwap", c.CompareAndSwap("name", "John", "Jane")},
+				{"GetContext", func() error { _, _, err := c.GetContext(context.Background(), "name"); return err }()},
+				{"SetJSON", c.SetJSON("json", 1)},
+				{"GetJSON", func() error { _, err := c.GetJSON("json", new(int)); return err }()},
+				{"ExportJSON", c.ExportJSON(&bytes.Buffer{})},
+			}
+			for _, tt := range tests {
+				if !errors.Is(tt.err, ErrCacheClosed) {
+					t.Errorf("%s: expected ErrCacheClosed, got %v", tt.name, tt.err)
+				}
+			}
+		})
+	}
+}
+
+// TestTTLCache_Close tests that Close stops the cleanup goroutine, reports the remaining
+// entries with EvictOnClose and makes the loader and snapshot methods fail
+func TestTTLCache_Close(t *testing.T) {
+	evicted := map[string]EvictReason{}
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:      time.Minute,
+		CleanupInterval: time.Millisecond,
+		EvictOnClose:    true,
+		OnEvict:         func(key string, _ interface{}, reason EvictReason) { evicted[key] = reason },
+	})
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if len(evicted) != 2 || evicted["a"] != EvictClosed || evicted["b"] != EvictClosed {
+		t.Errorf("expected both entries evicted as closed, got %v", evicted)
+	}
+	cache.Stop() // Stop after Close must not panic
+
+	calls := 0
+	if _, err := cache.GetOrCompute("a", func() (interface{}, error) { calls++; return 1, nil }); !errors.Is(err, ErrCacheClosed) {
+		t.Errorf("GetOrCompute: expected ErrCacheClosed, got %v", err)
+	}
+	if _, err := cache.GetOrComputeMany([]string{"a"}, func([]string) (map[string]interface{}, error) { calls++; return nil, nil }); !errors.Is(err, ErrCacheClosed) {
+		t.Errorf("GetOrComputeMany: expected ErrCacheClosed, got %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("loaders should not run on a closed cache, ran %d times", calls)
+	}
+	if err := cache.SaveSnapshot(&bytes.Buffer{}); !errors.Is(err, ErrCacheClosed) {
+		t.Errorf("SaveSnapshot: expected ErrCacheClosed, got %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package cache
+
+import "time"
+
+// GetOrCompute returns the live value for key, or runs fn and stores its result with the
+// default TTL. fn runs without holding the cache lock, so a slow computation only delays
+// callers of the same key, and concurrent misses for that key share a single call of fn.
+// Errors are returned to every waiting caller and are not cached.
+// With TTLCacheOptions.Breaker set, repeated errors open a circuit breaker: fn is not called
+// until the cooldown is over, and a miss returns the expired value if it is still in memory
+// or an error wrapping ErrBreakerOpen and the last error of fn
+func (c *TTLCache) GetOrCompute(key string, fn func() (interface{}, error)) (interface{}, error) {
+	if c.isClosed() {
+		return nil, ErrCacheClosed
+	}
+	if value, exists := c.Get(key); exists {
+		return value, nil
+	}
+
+	return c.computes.Do(key, func() (interface{}, error) {
+		// Another flight may have stored the value between our miss and starting this one,
+		// peeked so the miss already counted above isn't counted again
+		if value, exists := c.Peek(key); exists {
+			return value, nil
+		}
+		if err := c.breaker.allow(time.Now()); err != nil {
+			if value, exists := c.stale(key); exists {
+				return value, nil
+			}
+			return nil, err
+		}
+		start := time.Now()
+		value, err := callLoader(fn) // a panic must still reach record, or a half-open breaker never closes
+		c.observeLoad(start)
+		c.breaker.record(err, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		c.SetWithDefaultTTL(key, value)
+		return value, nil
+	})
+}
+
+// GetOrComputeMany returns the live values for keys, calling loader once with the keys that
+// are missing and storing what it returns with the default TTL. Keys another GetOrComputeMany
+// or GetOrCompute call is already loading are waited for instead of loaded again.
+// Keys the loader leaves out are absent from the result. On a loader error the values that
+// were available are returned together with the error. The breaker applies as for GetOrCompute,
+// while it is open missing keys are served from expired values when all of them are still in memory
+func (c *TTLCache) GetOrComputeMany(keys []string, loader func(missing []string) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	if c.isClosed() {
+		return nil, ErrCacheClosed
+	}
+	result := c.GetMany(keys)
+	var missing []string
+	for _, key := range keys {
+		if _, exists := result[key]; !exists {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	loaded, err := c.computes.DoMany(missing, func(owned []string) (map[string]interface{}, error) {
+		// Another flight may have stored some of them since our miss
+		values := c.peekMany(owned)
+		var toLoad []string
+		for _, key := range owned {
+			if _, exists := values[key]; !exists {
+				toLoad = append(toLoad, key)
+			}
+		}
+		if len(toLoad) == 0 {
+			return values, nil
+		}
+
+		if err := c.breaker.allow(time.Now()); err != nil {
+			served := true
+			for _, key := range toLoad {
+				if value, exists := c.stale(key); exists {
+					values[key] = value
+				} else {
+					served = false
+				}
+			}
+			if served {
+				return values, nil
+			}
+			return values, err
+		}
+		start := time.Now()
+		fresh, err := callMany(loader, toLoad)
+		c.observeLoad(start)
+		c.breaker.record(err, time.Now())
+		if err != nil {
+			return values, err
+		}
+		stored := make(map[string]interface{}, len(toLoad))
+		for _, key := range toLoad {
+			if value, ok := fresh[key]; ok {
+				stored[key] = value
+				values[key] = value
+			}
+		}
+		c.SetMany(stored)
+		return values, nil
+	})
+	for key, value := range loaded {
+		result[key] = value
+	}
+	return result, err
+}
+
+// observeLoad records a loader call that started at start when latency recording is enabled
+func (c *TTLCache) observeLoad(start time.Time) {
+	if c.loadLatency != nil {
+		c.loadLatency.observe(start)
+	}
+}
+
+// peekMany is GetMany without counting hits and misses or refreshing sliding expirations,
+// for re-checking keys whose lookup was already counted
+func (c *TTLCache) peekMany(keys []string) map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		if item, exists := c.data[key]; exists && !now.After(item.expiration) {
+			values[key] = item.value
+		}
+	}
+	return values
+}
@@ -0,0 +1,299 @@
+package cache
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestTTLCache_GetOrCompute tests hits, misses and that errors are not cached
+func TestTTLCache_GetOrCompute(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("cached", "hit")
+	value, err := cache.GetOrCompute("cached", func() (interface{}, error) {
+		t.Error("fn must not run on a hit")
+		return nil, nil
+	})
+	if err != nil || value != "hit" {
+		t.Errorf("expected hit, got %v, %v", value, err)
+	}
+
+	errLoad := errors.New("load failed")
+	if _, err := cache.GetOrCompute("key", func() (interface{}, error) { return nil, errLoad }); !errors.Is(err, errLoad) {
+		t.Errorf("expected the loader error, got %v", err)
+	}
+	if _, exists := cache.Get("key"); exists {
+		t.Error("a failed computation must not be cached")
+	}
+
+	value, err = cache.GetOrCompute("key", func() (interface{}, error) { return "computed", nil })
+	if err != nil || value != "computed" {
+		t.Errorf("expected computed, got %v, %v", value, err)
+	}
+	if value, _ := cache.Get("key"); value != "computed" {
+		t.Errorf("expected the computed value to be stored, got %v", value)
+	}
+}
+
+// TestTTLCache_GetOrComputeStats tests that a miss through GetOrCompute or GetOrComputeMany
+// is counted once, not again when the flight re-checks the cache
+func TestTTLCache_GetOrComputeStats(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	load := func() (interface{}, error) { return 1, nil }
+	cache.GetOrCompute("a", load)
+	cache.GetOrCompute("a", load)
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected 1 hit and 1 miss, got %d and %d", stats.Hits, stats.Misses)
+	}
+
+	loadMany := func(missing []string) (map[string]interface{}, error) {
+		values := make(map[string]interface{}, len(missing))
+		for _, key := range missing {
+			values[key] = 1
+		}
+		return values, nil
+	}
+	cache.GetOrComputeMany([]string{"a", "b", "c"}, loadMany)
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("expected 2 hits and 3 misses, got %d and %d", stats.Hits, stats.Misses)
+	}
+}
+
+// TestTTLCache_GetOrComputeDedupes tests that concurrent misses for a key run fn once
+func TestTTLCache_GetOrComputeDedupes(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.GetOrCompute("key", func() (interface{}, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			if err != nil || value != 42 {
+				t.Errorf("expected 42, got %v, %v", value, err)
+			}
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("expected fn to run once, ran %d times", calls.Load())
+	}
+}
+
+// TestTTLCache_GetOrComputePanic tests that a panicking loader releases every waiting caller with
+// ErrLoaderPanic and that the key can be computed again afterwards
+func TestTTLCache_GetOrComputePanic(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	release := make(chan struct{})
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			_, err := cache.GetOrCompute("key", func() (interface{}, error) {
+				<-release
+				panic("boom")
+			})
+			errs <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	for i := 0; i < 10; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrLoaderPanic) || !strings.Contains(err.Error(), "boom") {
+				t.Errorf("expected ErrLoaderPanic with the panic value, got %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("a caller is still blocked after the loader panicked")
+		}
+	}
+
+	value, err := cache.GetOrCompute("key", func() (interface{}, error) { return 1, nil })
+	if err != nil || value != 1 {
+		t.Errorf("expected the key to be computed again, got %v, %v", value, err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := cache.GetOrComputeMany([]string{"a", "b"}, func(missing []string) (map[string]interface{}, error) {
+			panic("boom")
+		})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrLoaderPanic) {
+			t.Errorf("expected ErrLoaderPanic from GetOrComputeMany, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetOrComputeMany is blocked after its loader panicked")
+	}
+}
+
+// TestTTLCache_GetOrComputeDoesNotBlock tests that a slow loader for one key leaves other keys usable
+func TestTTLCache_GetOrComputeDoesNotBlock(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+	cache.SetWithDefaultTTL("B", "b")
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.GetOrCompute("A", func() (interface{}, error) {
+			close(started)
+			<-release
+			return "a", nil
+		})
+	}()
+	<-started
+
+	ops := make(chan struct{})
+	go func() {
+		defer close(ops)
+		cache.Get("B")
+		cache.SetWithDefaultTTL("C", "c")
+		cache.Delete("C")
+	}()
+	select {
+	case <-ops:
+	case <-time.After(time.Second):
+		t.Fatal("operations on other keys were blocked by the loader for A")
+	}
+
+	close(release)
+	<-done
+	if value, _ := cache.Get("A"); value != "a" {
+		t.Errorf("expected a, got %v", value)
+	}
+}
+
+// TestTTLCache_GetOrComputeMany tests that the loader only sees the missing keys, once
+func TestTTLCache_GetOrComputeMany(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+	cache.SetMany(map[string]interface{}{"a": 1, "b": 2})
+
+	var calls [][]string
+	loader := func(missing []string) (map[string]interface{}, error) {
+		calls = append(calls, append([]string(nil), missing...))
+		values := make(map[string]interface{})
+		for _, key := range missing {
+			if key != "unknown" {
+				values[key] = key + "!"
+			}
+		}
+		return values, nil
+	}
+
+	result, err := cache.GetOrComputeMany([]string{"a", "c", "b", "d", "c", "unknown"}, loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 1 || strings.Join(sortedCopy(calls[0]), ",") != "c,d,unknown" {
+		t.Fatalf("expected one load of [c d unknown], got %v", calls)
+	}
+	want := map[string]interface{}{"a": 1, "b": 2, "c": "c!", "d": "d!"}
+	if len(result) != len(want) {
+		t.Errorf("expected %v, got %v", want, result)
+	}
+	for key, value := range want {
+		if result[key] != value {
+			t.Errorf("%s: expected %v, got %v", key, value, result[key])
+		}
+	}
+
+	// Everything loaded is cached now, only the unknown key is retried
+	calls = nil
+	cache.GetOrComputeMany([]string{"a", "c", "d", "unknown"}, loader)
+	if len(calls) != 1 || len(calls[0]) != 1 || calls[0][0] != "unknown" {
+		t.Errorf("expected one load of [unknown], got %v", calls)
+	}
+
+	errLoad := errors.New("load failed")
+	result, err = cache.GetOrComputeMany([]string{"a", "x"}, func([]string) (map[string]interface{}, error) {
+		return nil, errLoad
+	})
+	if !errors.Is(err, errLoad) || result["a"] != 1 {
+		t.Errorf("expected the cached values and the loader error, got %v %v", result, err)
+	}
+}
+
+// TestTTLCache_GetOrComputeManyCoalesces tests that overlapping concurrent batches load each key once
+func TestTTLCache_GetOrComputeManyCoalesces(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	var mu sync.Mutex
+	loads := make(map[string]int)
+	started, release := make(chan struct{}), make(chan struct{})
+	loader := func(block bool) func([]string) (map[string]interface{}, error) {
+		return func(missing []string) (map[string]interface{}, error) {
+			mu.Lock()
+			values := make(map[string]interface{})
+			for _, key := range missing {
+				loads[key]++
+				values[key] = key
+			}
+			mu.Unlock()
+			if block {
+				close(started)
+				<-release
+			}
+			return values, nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		cache.GetOrComputeMany([]string{"a", "b"}, loader(true))
+	}()
+	<-started
+	var second map[string]interface{}
+	go func() {
+		defer wg.Done()
+		second, _ = cache.GetOrComputeMany([]string{"b", "c"}, loader(false))
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if loads[key] != 1 {
+			t.Errorf("expected %s to be loaded once, got %d", key, loads[key])
+		}
+	}
+	if second["b"] != "b" || second["c"] != "c" {
+		t.Errorf("expected the second batch to get b and c, got %v", second)
+	}
+}
+
+// sortedCopy returns a sorted copy of keys
+func sortedCopy(keys []string) []string {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+	return keys
+}
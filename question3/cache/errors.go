@@ -0,0 +1,19 @@
+package cache
+
+import "errors"
+
+// Errors returned by the cache operations, possibly wrapped with the key and details,
+// so callers should compare with errors.Is. ErrBreakerOpen is defined next to the breaker
+var (
+	// ErrKeyNotFound is returned when an operation needs a live value and the key has none
+	ErrKeyNotFound = errors.New("cache: key not found")
+	// ErrTypeMismatch is returned when the stored value doesn't have the type the operation needs
+	ErrTypeMismatch = errors.New("cache: type mismatch")
+	// ErrValueMismatch is returned by CompareAndSwap when the stored value differs from the expected one
+	ErrValueMismatch = errors.New("cache: value mismatch")
+	// ErrCacheClosed is returned by operations on a cache that has been closed
+	ErrCacheClosed = errors.New("cache: closed")
+	// ErrLoaderPanic is returned by GetOrCompute and GetOrComputeMany to every caller sharing a
+	// loader call that panicked, wrapped with the panic value
+	ErrLoaderPanic = errors.New("cache: loader panicked")
+)
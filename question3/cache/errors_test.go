@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestSentinelErrors tests that every failure mode returns the matching sentinel for both caches
+func TestSentinelErrors(t *testing.T) {
+	type errorCache interface {
+		Set(key string, value interface{})
+		Increment(key string, delta int64) (int64, error)
+		CompareAndSwap(key string, old, new interface{}) error
+		GetJSON(key string, dest interface{}) (bool, error)
+	}
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+	caches := map[string]errorCache{"SimpleCache": NewSimpleCache(), "TTLCache": ttl}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			c.Set("name", "John")
+
+			tests := []struct {
+				name string
+				err  error
+				want error
+			}{
+				{"increment non-int64", func() error { _, err := c.Increment("name", 1); return err }(), ErrTypeMismatch},
+				{"json of non-bytes", func() error { _, err := c.GetJSON("name", new(string)); return err }(), ErrTypeMismatch},
+				{"swap missing key", c.CompareAndSwap("missing", nil, 1), ErrKeyNotFound},
+				{"swap different value", c.CompareAndSwap("name", "Jane", "Bob"), ErrValueMismatch},
+			}
+			for _, tt := range tests {
+				if !errors.Is(tt.err, tt.want) {
+					t.Errorf("%s: expected %v, got %v", tt.name, tt.want, tt.err)
+				}
+			}
+
+			if err := c.CompareAndSwap("name", "John", "Jane"); err != nil {
+				t.Errorf("expected a matching swap to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+// TestTTLCache_CompareAndSwapExpired tests that an expired key is reported as not found
+func TestTTLCache_CompareAndSwapExpired(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetWithTTL("session", "abc", -time.Second)
+	if err := cache.CompareAndSwap("session", "abc", "def"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+}
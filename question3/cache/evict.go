@@ -0,0 +1,55 @@
+package cache
+
+// EvictReason tells an OnEvict callback why an entry left the cache
+type EvictReason int
+
+const (
+	// EvictExpired means the entry's TTL ran out
+	EvictExpired EvictReason = iota + 1
+	// EvictDeleted means the entry was removed explicitly with Delete and friends
+	EvictDeleted
+	// EvictCapacity means the entry was dropped to make room for a new one
+	EvictCapacity
+	// EvictCleared means the entry was removed by Clear
+	EvictCleared
+	// EvictClosed means the entry was dropped by Close, see TTLCacheOptions.EvictOnClose
+	EvictClosed
+)
+
+// String returns the reason in lowercase, e.g. for logging
+func (r EvictReason) String() string {
+	switch r {
+	case EvictExpired:
+		return "expired"
+	case EvictDeleted:
+		return "deleted"
+	case EvictCapacity:
+		return "capacity"
+	case EvictCleared:
+		return "cleared"
+	case EvictClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// EvictFunc is called after an entry is removed from a cache. It runs after the cache's lock
+// is released, so it may call back into the cache
+type EvictFunc func(key string, value interface{}, reason EvictReason)
+
+// eviction is a removed entry waiting for its callback
+type eviction struct {
+	key    string
+	value  interface{}
+	reason EvictReason
+}
+
+// fireEvictions calls fn for every recorded eviction, fn may be nil
+func fireEvictions(fn EvictFunc, evicted []eviction) {
+	if fn == nil {
+		return
+	}
+	for _, e := range evicted {
+		fn(e.key, e.value, e.reason)
+	}
+}
@@ -0,0 +1,149 @@
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// evictRecorder collects OnEvict calls
+type evictRecorder struct {
+	mu      sync.Mutex
+	reasons map[string]EvictReason
+}
+
+func newEvictRecorder() *evictRecorder {
+	return &evictRecorder{reasons: make(map[string]EvictReason)}
+}
+
+func (r *evictRecorder) record(key string, value interface{}, reason EvictReason) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reasons[key] = reason
+}
+
+func (r *evictRecorder) reason(key string) EvictReason {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reasons[key]
+}
+
+// TestTTLCache_EvictReasons tests the reason passed for every TTLCache removal path
+func TestTTLCache_EvictReasons(t *testing.T) {
+	rec := newEvictRecorder()
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Minute, OnEvict: rec.record})
+	defer cache.Stop()
+
+	cache.SetWithTTL("expired", 1, 10*time.Millisecond)
+	cache.SetWithTTL("deleted-after-expiry", 1, 10*time.Millisecond)
+	cache.SetMany(map[string]interface{}{"deleted": 1, "many": 1, "prefix:a": 1, "cleared": 1})
+	time.Sleep(20 * time.Millisecond)
+
+	cache.deleteExpired()
+	cache.SetWithTTL("deleted-after-expiry", 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	cache.Delete("deleted-after-expiry")
+	cache.Delete("deleted")
+	cache.DeleteMany([]string{"many"})
+	cache.DeleteByPrefix("prefix:")
+	cache.Clear()
+
+	want := map[string]EvictReason{
+		"expired":              EvictExpired,
+		"deleted-after-expiry": EvictExpired,
+		"deleted":              EvictDeleted,
+		"many":                 EvictDeleted,
+		"prefix:a":             EvictDeleted,
+		"cleared":              EvictCleared,
+	}
+	for key, reason := range want {
+		if got := rec.reason(key); got != reason {
+			t.Errorf("%s: expected %v, got %v", key, reason, got)
+		}
+	}
+}
+
+// TestTTLCache_OnEvictReentrant tests that the callback may call back into the cache
+func TestTTLCache_OnEvictReentrant(t *testing.T) {
+	var cache *TTLCache
+	cache = NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL: time.Minute,
+		OnEvict: func(key string, value interface{}, reason EvictReason) {
+			cache.SetWithDefaultTTL("evicted:"+key, value)
+		},
+	})
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("key", "value")
+	cache.Delete("key")
+	if value, _ := cache.Get("evicted:key"); value != "value" {
+		t.Errorf("expected the callback to store the evicted value, got %v", value)
+	}
+}
+
+// TestCapacityCaches_EvictReasons tests capacity and delete reasons for the bounded caches
+func TestCapacityCaches_EvictReasons(t *testing.T) {
+	type boundedCache interface {
+		Cache
+		SetOnEvict(fn EvictFunc)
+	}
+	caches := map[string]boundedCache{
+		"LRUCache":   NewLRUCache(2),
+		"LFUCache":   NewLFUCache(2),
+		"SizedCache": NewSizedCache(2, func(interface{}) int64 { return 1 }),
+	}
+
+	for name, c := range caches {
+		t.Run(name, func(t *testing.T) {
+			rec := newEvictRecorder()
+			c.SetOnEvict(rec.record)
+
+			c.Set("a", 1)
+			c.Set("b", 2)
+			c.Set("c", 3) // evicts a
+			c.Delete("b")
+
+			if got := rec.reason("a"); got != EvictCapacity {
+				t.Errorf("a: expected capacity, got %v", got)
+			}
+			if got := rec.reason("b"); got != EvictDeleted {
+				t.Errorf("b: expected deleted, got %v", got)
+			}
+			if got := rec.reason("c"); got != 0 {
+				t.Errorf("c should not be evicted, got %v", got)
+			}
+		})
+	}
+}
+
+// TestTTLCache_ClearEvictsAll tests that Clear reports every entry and counts it as an eviction
+func TestTTLCache_ClearEvictsAll(t *testing.T) {
+	rec := newEvictRecorder()
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Minute, OnEvict: rec.record})
+	defer cache.Stop()
+
+	keys := []string{"a", "b", "c", "d"}
+	for _, key := range keys {
+		cache.SetWithDefaultTTL(key, key)
+	}
+	cache.Get("a")
+	cache.Get("missing")
+
+	cache.Clear()
+	for _, key := range keys {
+		if got := rec.reason(key); got != EvictCleared {
+			t.Errorf("%s: expected cleared, got %v", key, got)
+		}
+	}
+	if keys := cache.Keys(); len(keys) != 0 {
+		t.Errorf("expected an empty cache, got %v", keys)
+	}
+
+	stats := cache.Stats()
+	if stats.Evictions != uint64(len(keys)) {
+		t.Errorf("expected %d evictions, got %d", len(keys), stats.Evictions)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Clear must keep hits and misses, got %d/%d", stats.Hits, stats.Misses)
+	}
+}
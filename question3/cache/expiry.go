@@ -0,0 +1,48 @@
+package cache
+
+import "container/heap"
+
+// expirationHeap is a min-heap of the TTLCache items ordered by expiration, so cleanup only
+// visits entries that have expired instead of scanning the whole map. Every item in the map is
+// in the heap and knows its position, all changes go through put, reschedule and evict
+type expirationHeap []*cacheItem
+
+func (h expirationHeap) Len() int { return len(h) }
+
+func (h expirationHeap) Less(i, j int) bool { return h[i].expiration.Before(h[j].expiration) }
+
+func (h expirationHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expirationHeap) Push(x interface{}) {
+	item := x.(*cacheItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *expirationHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	old[len(old)-1] = nil // don't keep the evicted item reachable
+	item.index = -1
+	*h = old[:len(old)-1]
+	return item
+}
+
+// put stores item under key, replacing any previous item. The caller must hold the write lock
+func (c *TTLCache) put(key string, item *cacheItem) {
+	if old, exists := c.data[key]; exists {
+		heap.Remove(&c.expirations, old.index)
+	}
+	item.key = key
+	c.data[key] = item
+	heap.Push(&c.expirations, item)
+}
+
+// reschedule restores the heap order after item.expiration changed. The caller must hold the write lock
+func (c *TTLCache) reschedule(item *cacheItem) {
+	heap.Fix(&c.expirations, item.index)
+}
@@ -0,0 +1,156 @@
+package cache
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// checkExpirationHeap fails the test unless the heap holds exactly the items of the map in heap order
+func checkExpirationHeap(t *testing.T, c *TTLCache) {
+	t.Helper()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.expirations) != len(c.data) {
+		t.Fatalf("heap has %d items, map has %d", len(c.expirations), len(c.data))
+	}
+	for i, item := range c.expirations {
+		if item.index != i || c.data[item.key] != item {
+			t.Fatalf("heap item %d (%s) is out of sync with the map", i, item.key)
+		}
+		if parent := (i - 1) / 2; i > 0 && item.expiration.Before(c.expirations[parent].expiration) {
+			t.Fatalf("heap order broken at %d", i)
+		}
+	}
+}
+
+// TestTTLCache_ExpirationHeap tests that cleanup removes exactly the expired entries after
+// overwrites, touches, sliding reads and deletes changed their expirations
+func TestTTLCache_ExpirationHeap(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Hour, Sliding: true})
+	defer cache.Stop()
+
+	for i := 0; i < 50; i++ {
+		cache.SetWithTTL("short:"+strconv.Itoa(i), i, 20*time.Millisecond)
+		cache.Set("long:"+strconv.Itoa(i), i)
+	}
+	cache.Set("short:0", "overwritten") // now long-lived
+	cache.Touch("short:1", time.Hour)
+	cache.Touch("long:0", 10*time.Millisecond)
+	cache.Delete("short:2")
+	cache.Delete("long:1")
+	cache.Append("short:3", "list") // replaces the value, a new list gets the default TTL
+	checkExpirationHeap(t, cache)
+
+	time.Sleep(30 * time.Millisecond)
+	cache.deleteExpired()
+	checkExpirationHeap(t, cache)
+
+	keys := cache.Keys()
+	sort.Strings(keys)
+	// 50 long minus long:0 and long:1, plus short:0, short:1 and short:3
+	if len(keys) != 51 {
+		t.Fatalf("expected 51 entries after cleanup, got %d: %v", len(keys), keys)
+	}
+	for _, key := range []string{"short:0", "short:1", "short:3", "long:2"} {
+		if _, exists := cache.Peek(key); !exists {
+			t.Errorf("expected %s to survive cleanup", key)
+		}
+	}
+
+	cache.Clear()
+	checkExpirationHeap(t, cache)
+}
+
+// TestTTLCache_CleanupBatches tests that batched cleanup still removes every expired entry once
+func TestTTLCache_CleanupBatches(t *testing.T) {
+	evictions := 0
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:       time.Hour,
+		CleanupBatchSize: 7,
+		OnEvict:          func(string, interface{}, EvictReason) { evictions++ },
+	})
+	defer cache.Stop()
+
+	for i := 0; i < 100; i++ {
+		cache.SetWithTTL("expired:"+strconv.Itoa(i), i, -time.Second)
+		cache.Set("live:"+strconv.Itoa(i), i)
+	}
+
+	if done := cache.deleteExpiredBatch(); done {
+		t.Error("a single batch should not be able to remove 100 entries")
+	}
+	cache.deleteExpired()
+
+	if evictions != 100 {
+		t.Errorf("expected 100 evictions, got %d", evictions)
+	}
+	if keys := cache.Keys(); len(keys) != 100 {
+		t.Errorf("expected the 100 live entries to remain, got %d", len(keys))
+	}
+	checkExpirationHeap(t, cache)
+}
+
+// BenchmarkTTLCache_GetDuringCleanup reports the slowest Get (max-get-ns) while cleanup removes
+// 100k expired entries, with and without batching. With fewer CPUs than goroutines it also
+// includes the time the reading goroutine waited to be scheduled, compare with -cpu 4
+func BenchmarkTTLCache_GetDuringCleanup(b *testing.B) {
+	for _, batch := range []int{0, 1000} {
+		b.Run("batch="+strconv.Itoa(batch), func(b *testing.B) {
+			cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Hour, CleanupBatchSize: batch})
+			defer cache.Stop()
+			cache.Set("hot", "value")
+
+			var slowest time.Duration
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				for j := 0; j < 100000; j++ {
+					cache.SetWithTTL("expired:"+strconv.Itoa(j), j, -time.Second)
+				}
+				b.StartTimer()
+
+				done := make(chan struct{})
+				go func() {
+					cache.deleteExpired()
+					close(done)
+				}()
+				for running := true; running; {
+					select {
+					case <-done:
+						running = false
+					default:
+						start := time.Now()
+						cache.Get("hot")
+						slowest = max(slowest, time.Since(start))
+					}
+				}
+			}
+			b.ReportMetric(float64(slowest.Nanoseconds()), "max-get-ns")
+		})
+	}
+}
+
+// BenchmarkTTLCache_DeleteExpired measures a cleanup tick on a large cache where only a few
+// entries have expired
+func BenchmarkTTLCache_DeleteExpired(b *testing.B) {
+	const live, expiredPerTick = 100000, 10
+
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Hour}) // no cleanup goroutine
+	defer cache.Stop()
+	for i := 0; i < live; i++ {
+		cache.Set("live:"+strconv.Itoa(i), i)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		for j := 0; j < expiredPerTick; j++ {
+			cache.SetWithTTL("expired:"+strconv.Itoa(j), j, -time.Second)
+		}
+		b.StartTimer()
+
+		cache.deleteExpired()
+	}
+}
@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// exportEntry is the JSON shape of one entry written by ExportJSON
+type exportEntry struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt *time.Time      `json:"expires_at,omitempty"`
+}
+
+// ExportJSON writes every entry as {"key": {"value": ...}} for debugging.
+// Values that can't be marshaled to JSON are written as their %v string
+func (c *SimpleCache) ExportJSON(w io.Writer) error {
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return ErrCacheClosed
+	}
+	values := make(map[string]interface{}, len(c.data))
+	for key, item := range c.data {
+		values[key] = item.value
+	}
+	c.mu.RUnlock()
+
+	out := make(map[string]exportEntry, len(values))
+	for key, value := range values {
+		out[key] = exportEntry{Value: exportValue(value)}
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// ExportJSON writes every live entry as {"key": {"value": ..., "expires_at": ...}} for debugging.
+// Values that can't be marshaled to JSON are written as their %v string
+func (c *TTLCache) ExportJSON(w io.Writer) error {
+	type liveItem struct {
+		value      interface{}
+		expiration time.Time
+	}
+
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return ErrCacheClosed
+	}
+	now := time.Now()
+	items := make(map[string]liveItem, len(c.data))
+	for key, item := range c.data {
+		if now.After(item.expiration) {
+			continue
+		}
+		items[key] = liveItem{value: item.value, expiration: item.expiration}
+	}
+	c.mu.RUnlock()
+
+	// marshal outside the lock, values can be arbitrarily large
+	out := make(map[string]exportEntry, len(items))
+	for key, item := range items {
+		expiresAt := item.expiration
+		out[key] = exportEntry{Value: exportValue(item.value), ExpiresAt: &expiresAt}
+	}
+	return json.NewEncoder(w).Encode(out)
+}
+
+// exportValue marshals a value to JSON, falling back to its %v string so one bad value
+// doesn't fail the whole export
+func exportValue(value interface{}) json.RawMessage {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		raw, _ = json.Marshal(fmt.Sprintf("%v", value))
+	}
+	return raw
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestTTLCache_ExportJSON tests that expired entries are excluded and unmarshalable values are stringified
+func TestTTLCache_ExportJSON(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("name", "Alice")
+	cache.SetWithDefaultTTL("channel", make(chan int)) // not JSON serializable
+	cache.SetWithTTL("expired", "gone", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	var buf bytes.Buffer
+	if err := cache.ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+
+	var out map[string]struct {
+		Value     interface{} `json:"value"`
+		ExpiresAt *time.Time  `json:"expires_at"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	if _, exists := out["expired"]; exists {
+		t.Error("expired entry should be excluded")
+	}
+	if out["name"].Value != "Alice" || out["name"].ExpiresAt == nil {
+		t.Errorf("unexpected name entry: %+v", out["name"])
+	}
+	if _, ok := out["channel"].Value.(string); !ok {
+		t.Errorf("unserializable value should be exported as a string, got %v", out["channel"].Value)
+	}
+}
+
+// TestSimpleCache_ExportJSON tests that SimpleCache entries have no expires_at
+func TestSimpleCache_ExportJSON(t *testing.T) {
+	cache := NewSimpleCache()
+	cache.Set("count", 3)
+
+	var buf bytes.Buffer
+	if err := cache.ExportJSON(&buf); err != nil {
+		t.Fatalf("ExportJSON failed: %v", err)
+	}
+	if got := buf.String(); got != "{\"count\":{\"value\":3}}\n" {
+		t.Errorf("unexpected export: %s", got)
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SetJSON marshals v and stores the encoded bytes
+func (c *SimpleCache) SetJSON(key string, v interface{}) error {
+	if c.isClosed() {
+		return ErrCacheClosed
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal value for key %s: %w", key, err)
+	}
+	c.Set(key, data)
+	return nil
+}
+
+// GetJSON unmarshals the bytes stored by SetJSON into dest. A miss returns (false, nil)
+func (c *SimpleCache) GetJSON(key string, dest interface{}) (bool, error) {
+	if c.isClosed() {
+		return false, ErrCacheClosed
+	}
+	value, exists := c.Get(key)
+	return decodeJSONValue(key, value, exists, dest)
+}
+
+// SetJSON marshals v and stores the encoded bytes with the default TTL
+func (c *TTLCache) SetJSON(key string, v interface{}) error {
+	if c.isClosed() {
+		return ErrCacheClosed
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal value for key %s: %w", key, err)
+	}
+	c.SetWithDefaultTTL(key, data)
+	return nil
+}
+
+// GetJSON unmarshals the bytes stored by SetJSON into dest. A miss or an expired key returns (false, nil)
+func (c *TTLCache) GetJSON(key string, dest interface{}) (bool, error) {
+	if c.isClosed() {
+		return false, ErrCacheClosed
+	}
+	value, exists := c.Get(key)
+	return decodeJSONValue(key, value, exists, dest)
+}
+
+// decodeJSONValue unmarshals a cached []byte into dest, other values return ErrTypeMismatch
+func decodeJSONValue(key string, value interface{}, exists bool, dest interface{}) (bool, error) {
+	if !exists {
+		return false, nil
+	}
+	data, ok := value.([]byte)
+	if !ok {
+		return true, fmt.Errorf("%w: value for key %s is %T, not JSON bytes", ErrTypeMismatch, key, value)
+	}
+	if err := json.Unmarshal(data, dest); err != nil {
+		return true, fmt.Errorf("unmarshal value for key %s: %w", key, err)
+	}
+	return true, nil
+}
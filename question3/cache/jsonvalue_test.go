@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+type profile struct {
+	Name string   `json:"name"`
+	Age  int      `json:"age"`
+	Tags []string `json:"tags"`
+}
+
+// TestTTLCache_JSONRoundTrip tests storing a struct as JSON bytes and reading it back
+func TestTTLCache_JSONRoundTrip(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	in := profile{Name: "Alice", Age: 30, Tags: []string{"admin"}}
+	if err := cache.SetJSON("alice", in); err != nil {
+		t.Fatalf("SetJSON failed: %v", err)
+	}
+	if _, isBytes := cache.data["alice"].value.([]byte); !isBytes {
+		t.Error("value should be stored as bytes")
+	}
+
+	var out profile
+	found, err := cache.GetJSON("alice", &out)
+	if !found || err != nil {
+		t.Fatalf("expected a hit, got %v (err %v)", found, err)
+	}
+	if out.Name != "Alice" || out.Age != 30 || len(out.Tags) != 1 {
+		t.Errorf("unexpected round trip result: %+v", out)
+	}
+
+	if found, err := cache.GetJSON("missing", &out); found || err != nil {
+		t.Errorf("expected (false, nil) on a miss, got (%v, %v)", found, err)
+	}
+
+	cache.SetWithDefaultTTL("raw", "not bytes")
+	if _, err := cache.GetJSON("raw", &out); err == nil {
+		t.Error("expected an error for a non-JSON value")
+	}
+}
+
+// TestSimpleCache_JSONRoundTrip tests the SimpleCache variant and unmarshalable values
+func TestSimpleCache_JSONRoundTrip(t *testing.T) {
+	cache := NewSimpleCache()
+
+	if err := cache.SetJSON("bob", profile{Name: "Bob"}); err != nil {
+		t.Fatalf("SetJSON failed: %v", err)
+	}
+	var out profile
+	if found, err := cache.GetJSON("bob", &out); !found || err != nil || out.Name != "Bob" {
+		t.Errorf("unexpected result %+v (found %v, err %v)", out, found, err)
+	}
+
+	if err := cache.SetJSON("bad", make(chan int)); err == nil {
+		t.Error("expected a marshal error for a channel")
+	}
+}
@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// KeyFunc derives the string key a value is stored under from a composite key such as a struct.
+// It must return the same string for keys that are equal (==) and different strings for keys
+// that are not, so keys should be comparable values: structs of comparable fields, arrays,
+// basic types. Pointer keys are compared by address, like map keys
+type KeyFunc func(key interface{}) string
+
+// DefaultKeyFunc formats the key as %T:%#v, the type followed by every field, e.g.
+// cache.regionKey:cache.regionKey{UserID:1, Region:"eu"}, so int(1) and int64(1) don't collide.
+// Pointers and channels are formatted by address, so distinct pointers to equal values stay apart
+func DefaultKeyFunc(key interface{}) string {
+	switch reflect.ValueOf(key).Kind() {
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		return fmt.Sprintf("%T:%p", key, key)
+	}
+	return fmt.Sprintf("%T:%#v", key, key)
+}
+
+// KeyedCache is a view over a cache that accepts composite keys and stores them under the
+// string returned by its KeyFunc, replacing manual key concatenation. The parent's Keys and
+// Range report the derived strings
+type KeyedCache struct {
+	parent  Cache
+	keyFunc KeyFunc
+}
+
+// newKeyedCache creates a view of parent, a nil keyFunc uses DefaultKeyFunc
+func newKeyedCache(parent Cache, keyFunc KeyFunc) *KeyedCache {
+	if keyFunc == nil {
+		keyFunc = DefaultKeyFunc
+	}
+	return &KeyedCache{parent: parent, keyFunc: keyFunc}
+}
+
+// Set stores a value under the derived key
+func (k *KeyedCache) Set(key interface{}, value interface{}) {
+	k.parent.Set(k.keyFunc(key), value)
+}
+
+// Get retrieves a value by its composite key
+func (k *KeyedCache) Get(key interface{}) (interface{}, bool) {
+	return k.parent.Get(k.keyFunc(key))
+}
+
+// Delete removes a value by its composite key
+func (k *KeyedCache) Delete(key interface{}) {
+	k.parent.Delete(k.keyFunc(key))
+}
+
+// Key returns the string key the composite key is stored under in the parent
+func (k *KeyedCache) Key(key interface{}) string {
+	return k.keyFunc(key)
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+type regionKey struct {
+	UserID int
+	Region string
+}
+
+// TestKeyedCache_StructKeys tests storing, listing and deleting entries keyed by a struct
+func TestKeyedCache_StructKeys(t *testing.T) {
+	shared := NewTTLCache(time.Minute)
+	defer shared.Stop()
+	prefs := shared.WithKeyFunc(nil)
+
+	prefs.Set(regionKey{1, "eu"}, "metric")
+	prefs.Set(regionKey{1, "us"}, "imperial")
+	prefs.Set(regionKey{2, "eu"}, "metric")
+
+	if value, exists := prefs.Get(regionKey{1, "us"}); !exists || value != "imperial" {
+		t.Errorf("expected imperial for (1, us), got %v (exists %v)", value, exists)
+	}
+	if _, exists := prefs.Get(regionKey{3, "eu"}); exists {
+		t.Error("expected a miss for an unknown key")
+	}
+
+	keys := shared.Keys()
+	sort.Strings(keys)
+	want := []string{prefs.Key(regionKey{1, "eu"}), prefs.Key(regionKey{1, "us"}), prefs.Key(regionKey{2, "eu"})}
+	sort.Strings(want)
+	if fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("expected keys %v, got %v", want, keys)
+	}
+
+	prefs.Delete(regionKey{1, "eu"})
+	if _, exists := prefs.Get(regionKey{1, "eu"}); exists {
+		t.Error("deleted key should be gone")
+	}
+	if _, exists := prefs.Get(regionKey{2, "eu"}); !exists {
+		t.Error("deleting (1, eu) must not affect (2, eu)")
+	}
+}
+
+// TestKeyFunc tests that the default key function doesn't collide on values that a naive
+// concatenation would merge, and that a custom KeyFunc is used as given
+func TestKeyFunc(t *testing.T) {
+	type otherKey struct {
+		UserID int
+		Region string
+	}
+	collisions := [][2]interface{}{
+		{regionKey{1, "2eu"}, regionKey{12, "eu"}},
+		{regionKey{1, "eu"}, otherKey{1, "eu"}},
+		{1, "1"},
+		{1, int64(1)},
+		{&regionKey{1, "eu"}, &regionKey{1, "eu"}},
+	}
+	for _, pair := range collisions {
+		if DefaultKeyFunc(pair[0]) == DefaultKeyFunc(pair[1]) {
+			t.Errorf("%#v and %#v should not share the key %q", pair[0], pair[1], DefaultKeyFunc(pair[0]))
+		}
+	}
+	ptr := &regionKey{1, "eu"}
+	if DefaultKeyFunc(ptr) != DefaultKeyFunc(ptr) {
+		t.Error("the same pointer should always map to the same key")
+	}
+
+	simple := NewSimpleCache()
+	custom := simple.WithKeyFunc(func(key interface{}) string {
+		k := key.(regionKey)
+		return fmt.Sprintf("user:%d:%s", k.UserID, k.Region)
+	})
+	custom.Set(regionKey{7, "apac"}, true)
+	if _, exists := simple.Get("user:7:apac"); !exists {
+		t.Error("expected the custom key to be used in the parent cache")
+	}
+}
@@ -0,0 +1,177 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// lfuEntry is a cached value together with its position in the frequency buckets
+type lfuEntry struct {
+	key    string
+	value  interface{}
+	bucket *list.Element // element of LFUCache.buckets holding this entry
+	elem   *list.Element // element of the bucket's entries list
+}
+
+// lfuBucket groups entries that have been accessed the same number of times
+type lfuBucket struct {
+	freq    int
+	entries *list.List // front is the most recently used entry
+}
+
+// LFUCache is a capacity-bounded cache that evicts the least frequently used entry,
+// breaking ties by evicting the least recently used one.
+// Buckets are kept in a list ordered by frequency so every operation is O(1)
+type LFUCache struct {
+	data       map[string]*lfuEntry
+	buckets    *list.List // *lfuBucket ordered by ascending freq
+	maxEntries int
+	onEvict    EvictFunc
+	mu         sync.Mutex // Get changes frequencies, so every operation needs the full lock
+}
+
+// NewLFUCache creates an LFUCache holding at most maxEntries items, 0 means unlimited
+func NewLFUCache(maxEntries int) *LFUCache {
+	return &LFUCache{
+		data:       make(map[string]*lfuEntry),
+		buckets:    list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// Set stores a value, counting as an access for an existing key.
+// Adding a new key to a full cache evicts the least frequently used entry first
+func (c *LFUCache) Set(key string, value interface{}) {
+	var evicted []eviction
+	var onEvict EvictFunc // read under the lock, SetOnEvict may run concurrently
+	defer func() { fireEvictions(onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	onEvict = c.onEvict
+
+	if entry, exists := c.data[key]; exists {
+		entry.value = value
+		c.touch(entry)
+		return
+	}
+
+	if c.maxEntries > 0 && len(c.data) >= c.maxEntries {
+		if victim := c.evict(); victim != nil {
+			evicted = append(evicted, eviction{key: victim.key, value: victim.value, reason: EvictCapacity})
+		}
+	}
+
+	// New entries start with frequency 1, which is always the lowest bucket
+	front := c.buckets.Front()
+	if front == nil || front.Value.(*lfuBucket).freq != 1 {
+		front = c.buckets.PushFront(&lfuBucket{freq: 1, entries: list.New()})
+	}
+	entry := &lfuEntry{key: key, value: value, bucket: front}
+	entry.elem = front.Value.(*lfuBucket).entries.PushFront(entry)
+	c.data[key] = entry
+}
+
+// Get retrieves a value and increments its access frequency
+func (c *LFUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	c.touch(entry)
+	return entry.value, true
+}
+
+// Peek retrieves a value without counting it as an access
+func (c *LFUCache) Peek(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// GetContext is Get that first checks ctx, returning ctx.Err() if it is already done
+func (c *LFUCache) GetContext(ctx context.Context, key string) (interface{}, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	value, exists := c.Get(key)
+	return value, exists, nil
+}
+
+// Delete removes a value from the cache
+func (c *LFUCache) Delete(key string) {
+	var evicted []eviction
+	var onEvict EvictFunc // read under the lock, SetOnEvict may run concurrently
+	defer func() { fireEvictions(onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	onEvict = c.onEvict
+
+	if entry, exists := c.data[key]; exists {
+		c.remove(entry)
+		evicted = append(evicted, eviction{key: key, value: entry.value, reason: EvictDeleted})
+	}
+}
+
+// SetOnEvict registers fn to be called when an entry is evicted for capacity or deleted
+func (c *LFUCache) SetOnEvict(fn EvictFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Len returns the number of entries in the cache
+func (c *LFUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// touch moves an entry to the bucket for its next frequency
+func (c *LFUCache) touch(entry *lfuEntry) {
+	current := entry.bucket
+	freq := current.Value.(*lfuBucket).freq
+
+	next := current.Next()
+	if next == nil || next.Value.(*lfuBucket).freq != freq+1 {
+		next = c.buckets.InsertAfter(&lfuBucket{freq: freq + 1, entries: list.New()}, current)
+	}
+
+	c.unlink(entry)
+	entry.bucket = next
+	entry.elem = next.Value.(*lfuBucket).entries.PushFront(entry)
+}
+
+// evict removes and returns the least recently used entry of the lowest frequency bucket
+func (c *LFUCache) evict() *lfuEntry {
+	front := c.buckets.Front()
+	if front == nil {
+		return nil
+	}
+	oldest := front.Value.(*lfuBucket).entries.Back().Value.(*lfuEntry)
+	c.remove(oldest)
+	return oldest
+}
+
+// remove deletes an entry from the map and its bucket
+func (c *LFUCache) remove(entry *lfuEntry) {
+	c.unlink(entry)
+	delete(c.data, entry.key)
+}
+
+// unlink takes an entry out of its bucket, dropping the bucket once it is empty
+func (c *LFUCache) unlink(entry *lfuEntry) {
+	bucket := entry.bucket.Value.(*lfuBucket)
+	bucket.entries.Remove(entry.elem)
+	if bucket.entries.Len() == 0 {
+		c.buckets.Remove(entry.bucket)
+	}
+}
@@ -0,0 +1,70 @@
+package cache
+
+import "testing"
+
+// TestLFUCache_EvictsColdKeys tests that frequently accessed keys survive eviction
+func TestLFUCache_EvictsColdKeys(t *testing.T) {
+	cache := NewLFUCache(3)
+
+	cache.Set("hot1", 1)
+	cache.Set("hot2", 2)
+	cache.Set("cold", 3)
+
+	// Access the hot keys several times
+	for i := 0; i < 5; i++ {
+		cache.Get("hot1")
+		cache.Get("hot2")
+	}
+
+	// Adding a new key must evict the cold one
+	cache.Set("new", 4)
+	if _, exists := cache.Get("cold"); exists {
+		t.Error("cold should have been evicted")
+	}
+	if _, exists := cache.Get("hot1"); !exists {
+		t.Error("hot1 should still be cached")
+	}
+	if _, exists := cache.Get("hot2"); !exists {
+		t.Error("hot2 should still be cached")
+	}
+	if cache.Len() != 3 {
+		t.Errorf("expected 3 entries, got %d", cache.Len())
+	}
+}
+
+// TestLFUCache_TieBreaksByRecency tests that equal frequencies evict the least recently used key
+func TestLFUCache_TieBreaksByRecency(t *testing.T) {
+	cache := NewLFUCache(2)
+
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Get("b") // both have frequency 2, a is now the least recently used
+
+	cache.Set("c", 3)
+	if _, exists := cache.Get("a"); exists {
+		t.Error("a should have been evicted as the least recently used")
+	}
+	if _, exists := cache.Get("b"); !exists {
+		t.Error("b should still be cached")
+	}
+}
+
+// TestLFUCache_PeekKeepsFrequency tests that Peek does not count as an access
+func TestLFUCache_PeekKeepsFrequency(t *testing.T) {
+	cache := NewLFUCache(2)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("b")
+
+	for i := 0; i < 5; i++ {
+		if value, exists := cache.Peek("a"); !exists || value != 1 {
+			t.Fatalf("expected Peek to return 1, got %v, %v", value, exists)
+		}
+	}
+
+	cache.Set("c", 3)
+	if _, exists := cache.Peek("a"); exists {
+		t.Error("a should have been evicted, Peek must not raise its frequency")
+	}
+}
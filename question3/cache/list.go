@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"reflect"
+	"time"
+)
+
+// Lists are stored as []interface{}. Read them with ListGet, which returns a copy: Get returns
+// the stored slice itself, which later Appends may share
+
+// Append adds value to the end of the list at key under the write lock. A missing key starts a
+// new list, a key holding something other than a list is replaced by a new list
+func (c *SimpleCache) Append(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	if item, exists := c.data[key]; exists {
+		if list, ok := item.value.([]interface{}); ok {
+			item.value = append(list, value)
+			return
+		}
+	}
+	c.data[key] = newSimpleItem([]interface{}{value}, time.Now())
+}
+
+// ListGet returns a copy of the list at key, false if the key is missing or doesn't hold a list
+func (c *SimpleCache) ListGet(key string) ([]interface{}, bool) {
+	value, exists := c.Get(key)
+	return copyList(value, exists)
+}
+
+// RemoveFromList removes every element equal to value (reflect.DeepEqual) from the list at key.
+// Removing the last element deletes the key
+func (c *SimpleCache) RemoveFromList(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, exists := c.data[key]
+	if !exists {
+		return
+	}
+	list, ok := item.value.([]interface{})
+	if !ok {
+		return
+	}
+	if kept := removeFromList(list, value); len(kept) == 0 {
+		delete(c.data, key)
+	} else {
+		item.value = kept
+	}
+}
+
+// Append adds value to the end of the list at key under the write lock. The list shares one
+// expiration: appending keeps it, a missing or expired key starts a new list with the default TTL.
+// A key holding something other than a list is replaced by a new list
+func (c *TTLCache) Append(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	now := time.Now()
+	if item, exists := c.data[key]; exists && !now.After(item.expiration) {
+		if list, ok := item.value.([]interface{}); ok {
+			item.value = append(list, value)
+			return
+		}
+	}
+	c.put(key, &cacheItem{
+		entryStats: entryStats{createdAt: now},
+		value:      []interface{}{value},
+		expiration: c.expiresAt(now, c.defaultTTL),
+		ttl:        c.defaultTTL,
+	})
+	c.logger.Debug("cache append, new list", "key", key)
+}
+
+// ListGet returns a copy of the live list at key, false if the key is missing, expired or
+// doesn't hold a list. It counts as a Get, so it refreshes a sliding expiration
+func (c *TTLCache) ListGet(key string) ([]interface{}, bool) {
+	value, exists := c.Get(key)
+	return copyList(value, exists)
+}
+
+// RemoveFromList removes every element equal to value (reflect.DeepEqual) from the live list at key,
+// keeping its expiration. Removing the last element deletes the key
+func (c *TTLCache) RemoveFromList(key string, value interface{}) {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	item, exists := c.data[key]
+	if !exists || now.After(item.expiration) {
+		return
+	}
+	list, ok := item.value.([]interface{})
+	if !ok {
+		return
+	}
+	if kept := removeFromList(list, value); len(kept) == 0 {
+		c.evict(&evicted, key, item, now, EvictDeleted)
+	} else {
+		item.value = kept
+	}
+}
+
+// copyList copies a stored list so callers can't modify it
+func copyList(value interface{}, exists bool) ([]interface{}, bool) {
+	list, ok := value.([]interface{})
+	if !exists || !ok {
+		return nil, false
+	}
+	return append([]interface{}(nil), list...), true
+}
+
+// removeFromList returns a new slice without the elements equal to value
+func removeFromList(list []interface{}, value interface{}) []interface{} {
+	kept := make([]interface{}, 0, len(list))
+	for _, element := range list {
+		if !reflect.DeepEqual(element, value) {
+			kept = append(kept, element)
+		}
+	}
+	return kept
+}
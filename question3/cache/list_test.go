@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// listCache is implemented by both caches with list operations
+type listCache interface {
+	Cache
+	Append(key string, value interface{})
+	ListGet(key string) ([]interface{}, bool)
+	RemoveFromList(key string, value interface{})
+}
+
+// newListCaches returns one of each list cache, stopped with the test
+func newListCaches(t *testing.T) map[string]listCache {
+	ttl := NewTTLCache(time.Minute)
+	t.Cleanup(ttl.Stop)
+	return map[string]listCache{
+		"SimpleCache": NewSimpleCache(),
+		"TTLCache":    ttl,
+	}
+}
+
+// TestListOperations tests Append, ListGet and RemoveFromList on both caches
+func TestListOperations(t *testing.T) {
+	for name, cache := range newListCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, exists := cache.ListGet("tags"); exists {
+				t.Error("expected no list for a missing key")
+			}
+
+			cache.Append("tags", "go")
+			cache.Append("tags", "cache")
+			cache.Append("tags", "go")
+			list, exists := cache.ListGet("tags")
+			if !exists || fmt.Sprint(list) != "[go cache go]" {
+				t.Fatalf("expected [go cache go], got %v, %v", list, exists)
+			}
+
+			list[0] = "modified"
+			if list, _ := cache.ListGet("tags"); list[0] != "go" {
+				t.Error("ListGet must return a copy")
+			}
+
+			cache.RemoveFromList("tags", "go")
+			if list, _ := cache.ListGet("tags"); fmt.Sprint(list) != "[cache]" {
+				t.Errorf("expected every go to be removed, got %v", list)
+			}
+			cache.RemoveFromList("tags", "cache")
+			if _, exists := cache.Get("tags"); exists {
+				t.Error("removing the last element should delete the key")
+			}
+
+			cache.Set("plain", 42)
+			if _, exists := cache.ListGet("plain"); exists {
+				t.Error("ListGet of a non-list value should return false")
+			}
+			cache.Append("plain", 1)
+			if list, _ := cache.ListGet("plain"); fmt.Sprint(list) != "[1]" {
+				t.Errorf("expected Append to replace a non-list value, got %v", list)
+			}
+		})
+	}
+}
+
+// TestListOperations_ConcurrentAppend tests that concurrent appends to one key are all kept
+func TestListOperations_ConcurrentAppend(t *testing.T) {
+	for name, cache := range newListCaches(t) {
+		t.Run(name, func(t *testing.T) {
+			const goroutines, perGoroutine = 20, 50
+			var wg sync.WaitGroup
+			for g := 0; g < goroutines; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := 0; i < perGoroutine; i++ {
+						cache.Append("index", g*perGoroutine+i)
+						cache.ListGet("index")
+					}
+				}(g)
+			}
+			wg.Wait()
+
+			list, _ := cache.ListGet("index")
+			values := make([]int, len(list))
+			for i, v := range list {
+				values[i] = v.(int)
+			}
+			sort.Ints(values)
+			if len(values) != goroutines*perGoroutine {
+				t.Fatalf("expected %d values, got %d", goroutines*perGoroutine, len(values))
+			}
+			for i, v := range values {
+				if v != i {
+					t.Fatalf("expected every value once, value %d is %d", i, v)
+				}
+			}
+		})
+	}
+}
+
+// TestTTLCache_ListSharesExpiration tests that appends keep the list's expiration
+func TestTTLCache_ListSharesExpiration(t *testing.T) {
+	cache := NewTTLCache(30 * time.Millisecond)
+	defer cache.Stop()
+
+	cache.Append("tags", "a")
+	time.Sleep(20 * time.Millisecond)
+	cache.Append("tags", "b")
+	time.Sleep(20 * time.Millisecond)
+
+	if list, exists := cache.ListGet("tags"); exists {
+		t.Errorf("expected the whole list to expire with its first element, got %v", list)
+	}
+	cache.Append("tags", "c")
+	if list, _ := cache.ListGet("tags"); fmt.Sprint(list) != "[c]" {
+		t.Errorf("expected an expired list to start over, got %v", list)
+	}
+}
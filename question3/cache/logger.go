@@ -0,0 +1,17 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardLogger is the default cache logger, it drops every record
+var discardLogger = slog.New(discardHandler{})
+
+// discardHandler is a slog.Handler that is never enabled
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureHandler records the level of every log record it receives
+type captureHandler struct {
+	mu     sync.Mutex
+	levels []slog.Level
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.levels = append(h.levels, r.Level)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h *captureHandler) WithGroup(string) slog.Handler      { return h }
+
+// TestTTLCache_Logger tests that routine operations only log at Debug and the default logger is silent
+func TestTTLCache_Logger(t *testing.T) {
+	handler := &captureHandler{}
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{
+		DefaultTTL:      10 * time.Millisecond,
+		CleanupInterval: 5 * time.Millisecond,
+		Logger:          slog.New(handler),
+	})
+
+	cache.SetWithDefaultTTL("key", "value")
+	cache.Get("key")
+	cache.Touch("key", 10*time.Millisecond)
+	cache.Delete("key")
+	cache.SetWithDefaultTTL("expiring", "value")
+	time.Sleep(30 * time.Millisecond)
+	cache.Stop()
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	if len(handler.levels) == 0 {
+		t.Fatal("expected debug records from the injected logger")
+	}
+	for _, level := range handler.levels {
+		if level >= slog.LevelInfo {
+			t.Errorf("routine operation logged at %v", level)
+		}
+	}
+
+	// Without a logger nothing is enabled at all
+	silent := NewTTLCache(time.Minute)
+	defer silent.Stop()
+	if silent.logger.Enabled(context.Background(), slog.LevelError) {
+		t.Error("default logger should discard every level")
+	}
+}
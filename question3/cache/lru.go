@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruEntry is a cached value stored in the LRU list
+type lruEntry struct {
+	key   string
+	value interface{}
+}
+
+// LRUCache is a SimpleCache with a capacity cap that evicts the least recently used entry
+type LRUCache struct {
+	data       map[string]*list.Element
+	order      *list.List // front is the most recently used entry
+	maxEntries int
+	onEvict    EvictFunc
+	mu         sync.Mutex // Get changes the recency order, so every operation needs the full lock
+}
+
+// NewLRUCache creates an LRUCache holding at most maxEntries items, 0 means unlimited
+func NewLRUCache(maxEntries int) *LRUCache {
+	return &LRUCache{
+		data:       make(map[string]*list.Element),
+		order:      list.New(),
+		maxEntries: maxEntries,
+	}
+}
+
+// SetOnEvict registers fn to be called when an entry is evicted for capacity or deleted
+func (c *LRUCache) SetOnEvict(fn EvictFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Set stores a value and marks it as most recently used, evicting the oldest entry when full
+func (c *LRUCache) Set(key string, value interface{}) {
+	var evicted []eviction
+	var onEvict EvictFunc // read under the lock, SetOnEvict may run concurrently
+	defer func() { fireEvictions(onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	onEvict = c.onEvict
+
+	if elem, exists := c.data[key]; exists {
+		elem.Value.(*lruEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	if c.maxEntries > 0 && len(c.data) >= c.maxEntries {
+		oldest := c.order.Remove(c.order.Back()).(*lruEntry)
+		delete(c.data, oldest.key)
+		evicted = append(evicted, eviction{key: oldest.key, value: oldest.value, reason: EvictCapacity})
+	}
+
+	c.data[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+}
+
+// Get retrieves a value and marks it as most recently used
+func (c *LRUCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+// Peek retrieves a value without marking it as recently used
+func (c *LRUCache) Peek(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	return elem.Value.(*lruEntry).value, true
+}
+
+// Delete removes a value from the cache
+func (c *LRUCache) Delete(key string) {
+	var evicted []eviction
+	var onEvict EvictFunc // read under the lock, SetOnEvict may run concurrently
+	defer func() { fireEvictions(onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	onEvict = c.onEvict
+
+	if elem, exists := c.data[key]; exists {
+		entry := c.order.Remove(elem).(*lruEntry)
+		delete(c.data, key)
+		evicted = append(evicted, eviction{key: key, value: entry.value, reason: EvictDeleted})
+	}
+}
+
+// Len returns the number of entries in the cache
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
@@ -0,0 +1,42 @@
+package cache
+
+import "testing"
+
+// TestLRUCache_EvictsLeastRecentlyUsed tests that Get refreshes recency before eviction
+func TestLRUCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a")
+	cache.Set("c", 3)
+
+	if _, exists := cache.Get("b"); exists {
+		t.Error("b should have been evicted")
+	}
+	if _, exists := cache.Get("a"); !exists {
+		t.Error("a should still be cached")
+	}
+}
+
+// TestLRUCache_PeekKeepsOrder tests that Peek does not change the recency order
+func TestLRUCache_PeekKeepsOrder(t *testing.T) {
+	cache := NewLRUCache(2)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+
+	if value, exists := cache.Peek("a"); !exists || value != 1 {
+		t.Fatalf("expected Peek to return 1, got %v, %v", value, exists)
+	}
+	if _, exists := cache.Peek("missing"); exists {
+		t.Error("Peek of a missing key should return false")
+	}
+
+	// a is still the least recently used entry, so it is the one evicted
+	cache.Set("c", 3)
+	if _, exists := cache.Peek("a"); exists {
+		t.Error("a should have been evicted, Peek must not refresh it")
+	}
+	if _, exists := cache.Peek("b"); !exists {
+		t.Error("b should still be cached")
+	}
+}
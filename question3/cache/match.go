@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"path"
+	"time"
+)
+
+// keyMatches reports whether key matches a path.Match glob. "*" does not cross "/" and a
+// malformed pattern matches nothing
+func keyMatches(pattern, key string) bool {
+	matched, err := path.Match(pattern, key)
+	return err == nil && matched
+}
+
+// KeysMatching returns the keys matching a glob such as "user:*:profile", see path.Match
+func (c *SimpleCache) KeysMatching(pattern string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var keys []string
+	for key := range c.data {
+		if keyMatches(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// DeleteMatching removes every key matching a glob under a single lock and returns how many it removed
+func (c *SimpleCache) DeleteMatching(pattern string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := 0
+	for key := range c.data {
+		if keyMatches(pattern, key) {
+			delete(c.data, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// KeysMatching returns the live keys matching a glob such as "user:*:profile", see path.Match
+func (c *TTLCache) KeysMatching(pattern string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	var keys []string
+	for key, item := range c.data {
+		if !now.After(item.expiration) && keyMatches(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// DeleteMatching removes every key matching a glob under a single lock and returns how many
+// held a live value. Matching expired entries are removed too, OnEvict fires for all of them
+func (c *TTLCache) DeleteMatching(pattern string) int {
+	var evicted []eviction
+	defer func() { fireEvictions(c.onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	removed := 0
+	for key, item := range c.data {
+		if !keyMatches(pattern, key) {
+			continue
+		}
+		if !now.After(item.expiration) {
+			removed++
+		}
+		c.evict(&evicted, key, item, now, EvictDeleted)
+	}
+	c.logger.Debug("cache delete matching", "removed", removed, "pattern", pattern)
+	return removed
+}
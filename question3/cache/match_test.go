@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// matchKeys are stored in both caches by the matching tests
+var matchKeys = []string{"user:1:profile", "user:2:profile", "user:2:settings", "session:abc", "user:10:profile"}
+
+// TestKeysMatching tests glob patterns against SimpleCache and TTLCache keys
+func TestKeysMatching(t *testing.T) {
+	simple := NewSimpleCache()
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+	for _, key := range matchKeys {
+		simple.Set(key, true)
+		ttl.SetWithDefaultTTL(key, true)
+	}
+	ttl.SetWithTTL("user:3:profile", true, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"*", matchKeys},
+		{"user:*:profile", []string{"user:1:profile", "user:10:profile", "user:2:profile"}},
+		{"user:?:profile", []string{"user:1:profile", "user:2:profile"}},
+		{"user:2:*", []string{"user:2:profile", "user:2:settings"}},
+		{"session:[a-c]bc", []string{"session:abc"}},
+		{"session:abc", []string{"session:abc"}},
+		{"nothing*", nil},
+		{"[", nil}, // malformed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			want := append([]string(nil), tt.want...)
+			sort.Strings(want)
+			for name, keys := range map[string][]string{
+				"SimpleCache": simple.KeysMatching(tt.pattern),
+				"TTLCache":    ttl.KeysMatching(tt.pattern),
+			} {
+				sort.Strings(keys)
+				if len(keys) != len(want) {
+					t.Errorf("%s: expected %v, got %v", name, want, keys)
+					continue
+				}
+				for i := range keys {
+					if keys[i] != want[i] {
+						t.Errorf("%s: expected %v, got %v", name, want, keys)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestDeleteMatching tests removal counts and OnEvict for matching keys
+func TestDeleteMatching(t *testing.T) {
+	simple := NewSimpleCache()
+	for _, key := range matchKeys {
+		simple.Set(key, true)
+	}
+	if removed := simple.DeleteMatching("user:*:profile"); removed != 3 {
+		t.Errorf("expected 3 removed, got %d", removed)
+	}
+	if _, exists := simple.Get("user:2:settings"); !exists {
+		t.Error("user:2:settings should survive")
+	}
+
+	rec := newEvictRecorder()
+	ttl := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Minute, OnEvict: rec.record})
+	defer ttl.Stop()
+	for _, key := range matchKeys {
+		ttl.SetWithDefaultTTL(key, true)
+	}
+	ttl.SetWithTTL("user:3:profile", true, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// The expired entry is removed but not counted
+	if removed := ttl.DeleteMatching("user:*:profile"); removed != 3 {
+		t.Errorf("expected 3 removed, got %d", removed)
+	}
+	if _, exists := ttl.data["user:3:profile"]; exists {
+		t.Error("expired matching entry should be removed")
+	}
+	if got := rec.reason("user:1:profile"); got != EvictDeleted {
+		t.Errorf("expected deleted, got %v", got)
+	}
+	if got := rec.reason("user:3:profile"); got != EvictExpired {
+		t.Errorf("expected expired, got %v", got)
+	}
+	if got := rec.reason("session:abc"); got != 0 {
+		t.Errorf("session:abc should not be evicted, got %v", got)
+	}
+}
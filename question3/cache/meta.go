@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// EntryMeta describes how an entry has been used since it was last stored
+type EntryMeta struct {
+	CreatedAt  time.Time // when the current value was stored, overwriting the key resets it
+	LastAccess time.Time // last Get that returned the entry, zero until the first hit
+	Hits       uint64
+}
+
+// entryStats is embedded by the item types. Reads update it under the read lock,
+// so the fields a Get touches are atomic
+type entryStats struct {
+	createdAt  time.Time
+	lastAccess atomic.Int64 // unix nanoseconds, 0 until the first hit
+	hits       atomic.Uint64
+}
+
+// touch records a hit at now
+func (s *entryStats) touch(now time.Time) {
+	s.hits.Add(1)
+	s.lastAccess.Store(now.UnixNano())
+}
+
+// meta returns a snapshot of the stats
+func (s *entryStats) meta() EntryMeta {
+	meta := EntryMeta{CreatedAt: s.createdAt, Hits: s.hits.Load()}
+	if nanos := s.lastAccess.Load(); nanos != 0 {
+		meta.LastAccess = time.Unix(0, nanos)
+	}
+	return meta
+}
+
+// simpleItem wraps a SimpleCache value with its usage stats
+type simpleItem struct {
+	entryStats
+	value interface{}
+}
+
+// newSimpleItem wraps value as a freshly stored entry
+func newSimpleItem(value interface{}, now time.Time) *simpleItem {
+	return &simpleItem{entryStats: entryStats{createdAt: now}, value: value}
+}
+
+// EntryInfo returns the usage metadata of key, or false if it is missing
+func (c *SimpleCache) EntryInfo(key string) (EntryMeta, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.data[key]
+	if !exists {
+		return EntryMeta{}, false
+	}
+	return item.meta(), true
+}
+
+// EntryInfo returns the usage metadata of a live entry, or false if key is missing or expired.
+// Like Peek it does not count as an access
+func (c *TTLCache) EntryInfo(key string) (EntryMeta, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, exists := c.data[key]
+	if !exists || time.Now().After(item.expiration) {
+		return EntryMeta{}, false
+	}
+	return item.meta(), true
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// entryInfoCache is implemented by both caches that track entry metadata
+type entryInfoCache interface {
+	Cache
+	EntryInfo(key string) (EntryMeta, bool)
+}
+
+// TestEntryInfo tests that Get counts hits and advances the last access time, and Set resets both
+func TestEntryInfo(t *testing.T) {
+	ttl := NewTTLCache(time.Minute)
+	defer ttl.Stop()
+
+	caches := map[string]entryInfoCache{
+		"SimpleCache": NewSimpleCache(),
+		"TTLCache":    ttl,
+	}
+	for name, cache := range caches {
+		t.Run(name, func(t *testing.T) {
+			before := time.Now()
+			cache.Set("key", "value")
+
+			meta, exists := cache.EntryInfo("key")
+			if !exists {
+				t.Fatal("expected metadata for a stored key")
+			}
+			if meta.CreatedAt.Before(before) || meta.Hits != 0 || !meta.LastAccess.IsZero() {
+				t.Fatalf("unexpected metadata for a fresh entry: %+v", meta)
+			}
+
+			cache.Get("key")
+			first, _ := cache.EntryInfo("key")
+			time.Sleep(2 * time.Millisecond)
+			cache.Get("key")
+			second, _ := cache.EntryInfo("key")
+
+			if first.Hits != 1 || second.Hits != 2 {
+				t.Errorf("expected hits 1 then 2, got %d then %d", first.Hits, second.Hits)
+			}
+			if !second.LastAccess.After(first.LastAccess) {
+				t.Errorf("expected last access to advance, got %v then %v", first.LastAccess, second.LastAccess)
+			}
+			if !second.CreatedAt.Equal(meta.CreatedAt) {
+				t.Error("reads must not change the creation time")
+			}
+
+			// a miss is not a hit
+			cache.Get("missing")
+			if meta, _ := cache.EntryInfo("key"); meta.Hits != 2 {
+				t.Errorf("expected hits to stay at 2, got %d", meta.Hits)
+			}
+
+			cache.Set("key", "other")
+			if meta, _ := cache.EntryInfo("key"); meta.Hits != 0 || !meta.CreatedAt.After(second.CreatedAt) {
+				t.Errorf("expected overwrite to reset the metadata, got %+v", meta)
+			}
+
+			if _, exists := cache.EntryInfo("missing"); exists {
+				t.Error("expected no metadata for a missing key")
+			}
+		})
+	}
+}
+
+// TestTTLCache_EntryInfo tests that Peek is not counted as a hit and expired entries have no metadata
+func TestTTLCache_EntryInfo(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.Set("peeked", "value")
+	cache.Peek("peeked")
+	if meta, _ := cache.EntryInfo("peeked"); meta.Hits != 0 {
+		t.Errorf("expected Peek not to count as a hit, got %d hits", meta.Hits)
+	}
+
+	cache.SetWithTTL("key", "value", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, exists := cache.EntryInfo("key"); exists {
+		t.Error("expected no metadata for an expired key")
+	}
+}
@@ -0,0 +1,60 @@
+package cache
+
+import "strings"
+
+// rangeCache is a Cache that can also list and iterate its entries
+type rangeCache interface {
+	Cache
+	Keys() []string
+	Range(fn func(key string, value interface{}) bool)
+}
+
+// PrefixedCache is a lightweight view over a shared cache that prepends prefix + ":"
+// to every key, giving subsystems logical isolation without separate cache instances
+type PrefixedCache struct {
+	parent rangeCache
+	prefix string // includes the trailing ":"
+}
+
+var _ Cache = (*PrefixedCache)(nil)
+
+// newPrefixedCache creates a view of parent for the given prefix
+func newPrefixedCache(parent rangeCache, prefix string) *PrefixedCache {
+	return &PrefixedCache{parent: parent, prefix: prefix + ":"}
+}
+
+// Set stores a value under the prefixed key
+func (p *PrefixedCache) Set(key string, value interface{}) {
+	p.parent.Set(p.prefix+key, value)
+}
+
+// Get retrieves a value by its unprefixed key
+func (p *PrefixedCache) Get(key string) (interface{}, bool) {
+	return p.parent.Get(p.prefix + key)
+}
+
+// Delete removes a value by its unprefixed key
+func (p *PrefixedCache) Delete(key string) {
+	p.parent.Delete(p.prefix + key)
+}
+
+// Keys returns the keys belonging to this view with the prefix stripped
+func (p *PrefixedCache) Keys() []string {
+	var keys []string
+	for _, key := range p.parent.Keys() {
+		if strings.HasPrefix(key, p.prefix) {
+			keys = append(keys, strings.TrimPrefix(key, p.prefix))
+		}
+	}
+	return keys
+}
+
+// Range calls fn for every entry of this view with the prefix stripped, see the parent's Range
+func (p *PrefixedCache) Range(fn func(key string, value interface{}) bool) {
+	p.parent.Range(func(key string, value interface{}) bool {
+		if !strings.HasPrefix(key, p.prefix) {
+			return true
+		}
+		return fn(strings.TrimPrefix(key, p.prefix), value)
+	})
+}
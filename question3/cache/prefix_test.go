@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPrefixedCache_Isolation tests that two prefixes don't see each other's keys
+func TestPrefixedCache_Isolation(t *testing.T) {
+	shared := NewTTLCache(time.Minute)
+	defer shared.Stop()
+
+	users := shared.WithPrefix("users")
+	orders := shared.WithPrefix("orders")
+
+	users.Set("1", "Alice")
+	orders.Set("1", "order-42")
+
+	if value, _ := users.Get("1"); value != "Alice" {
+		t.Errorf("users view expected Alice, got %v", value)
+	}
+	if value, _ := orders.Get("1"); value != "order-42" {
+		t.Errorf("orders view expected order-42, got %v", value)
+	}
+
+	// Both live in the shared storage under their namespaced keys
+	if _, exists := shared.Get("users:1"); !exists {
+		t.Error("shared cache should hold users:1")
+	}
+
+	keys := users.Keys()
+	if len(keys) != 1 || keys[0] != "1" {
+		t.Errorf("users view should only list its own stripped key, got %v", keys)
+	}
+
+	orders.Delete("1")
+	if _, exists := users.Get("1"); !exists {
+		t.Error("deleting from orders must not affect users")
+	}
+}
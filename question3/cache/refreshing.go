@@ -0,0 +1,56 @@
+package cache
+
+import "time"
+
+// Loader computes the value for a key on a miss or a refresh
+type Loader func(key string) (interface{}, error)
+
+// RefreshingCache wraps a TTLCache and a Loader. Reads within staleWindow of expiry return the
+// current value immediately and reload it in the background, so hot keys never block on the loader.
+// A failed reload keeps serving the stale value until it really expires
+type RefreshingCache struct {
+	cache       *TTLCache
+	loader      Loader
+	staleWindow time.Duration
+	flights     flightGroup
+}
+
+// NewRefreshingCache creates a RefreshingCache storing loaded values in c with its default TTL
+func NewRefreshingCache(c *TTLCache, loader Loader, staleWindow time.Duration) *RefreshingCache {
+	return &RefreshingCache{
+		cache:       c,
+		loader:      loader,
+		staleWindow: staleWindow,
+	}
+}
+
+// Get returns the cached value, loading it synchronously on a miss. Concurrent loads
+// and refreshes of the same key are collapsed into a single loader call
+func (c *RefreshingCache) Get(key string) (interface{}, error) {
+	value, remaining, exists := c.cache.GetWithTTL(key)
+	if exists {
+		if remaining <= c.staleWindow {
+			c.flights.DoAsync(key, func() (interface{}, error) { return c.load(key) })
+		}
+		return value, nil
+	}
+	return c.flights.Do(key, func() (interface{}, error) { return c.load(key) })
+}
+
+// load calls the loader and stores a successful result
+func (c *RefreshingCache) load(key string) (interface{}, error) {
+	value, err := c.loader(key)
+	if err != nil {
+		c.cache.logger.Warn("cache load failed", "key", key, "error", err)
+		return nil, err
+	}
+	c.cache.SetWithDefaultTTL(key, value)
+	return value, nil
+}
+
+// Close waits for the background refreshes started by Get to finish. The wrapped TTLCache is
+// left running since it belongs to the caller, and Get keeps working afterwards
+func (c *RefreshingCache) Close() error {
+	c.flights.async.Wait()
+	return nil
+}
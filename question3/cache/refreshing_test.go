@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRefreshingCache_StaleWindow tests that a read in the stale window returns the old value and reloads once
+func TestRefreshingCache_StaleWindow(t *testing.T) {
+	ttl := NewTTLCache(100 * time.Millisecond)
+	defer ttl.Stop()
+
+	var calls atomic.Int64
+	cache := NewRefreshingCache(ttl, func(key string) (interface{}, error) {
+		return calls.Add(1), nil
+	}, 50*time.Millisecond)
+	defer cache.Close()
+
+	// Concurrent misses share one load
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := cache.Get("key"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("expected 1 load, got %d", calls.Load())
+	}
+
+	// Outside the stale window nothing is reloaded
+	cache.Get("key")
+	cache.Close()
+	if calls.Load() != 1 {
+		t.Fatalf("fresh read should not reload, got %d loads", calls.Load())
+	}
+
+	// Inside the stale window the old value is served and a reload runs in the background
+	time.Sleep(60 * time.Millisecond)
+	value, _ := cache.Get("key")
+	if value != int64(1) {
+		t.Errorf("expected the stale value 1, got %v", value)
+	}
+	cache.Close()
+	if calls.Load() != 2 {
+		t.Fatalf("expected a background reload, got %d loads", calls.Load())
+	}
+	if value, _ := cache.Get("key"); value != int64(2) {
+		t.Errorf("expected the refreshed value 2, got %v", value)
+	}
+}
+
+// TestRefreshingCache_FailedReload tests that the stale value is served until hard expiry when reloads fail
+func TestRefreshingCache_FailedReload(t *testing.T) {
+	ttl := NewTTLCache(100 * time.Millisecond)
+	defer ttl.Stop()
+
+	var calls atomic.Int64
+	cache := NewRefreshingCache(ttl, func(key string) (interface{}, error) {
+		if calls.Add(1) > 1 {
+			return nil, errors.New("backend down")
+		}
+		return "v1", nil
+	}, 50*time.Millisecond)
+	defer cache.Close()
+
+	cache.Get("key")
+	time.Sleep(60 * time.Millisecond)
+	if value, err := cache.Get("key"); err != nil || value != "v1" {
+		t.Errorf("expected stale v1, got %v (err %v)", value, err)
+	}
+	cache.Close()
+	if value, _ := cache.Get("key"); value != "v1" {
+		t.Errorf("failed reload should keep serving v1, got %v", value)
+	}
+	cache.Close()
+
+	// After hard expiry the failure surfaces
+	time.Sleep(50 * time.Millisecond)
+	if _, err := cache.Get("key"); err == nil {
+		t.Error("expected the loader error after expiry")
+	}
+}
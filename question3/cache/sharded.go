@@ -0,0 +1,84 @@
+package cache
+
+import "sync"
+
+// defaultShardCount is used when NewShardedCache is given a non-positive count
+const defaultShardCount = 32
+
+// cacheShard is one partition of a ShardedCache with its own lock
+type cacheShard struct {
+	data map[string]interface{}
+	mu   sync.RWMutex
+}
+
+// ShardedCache spreads keys across several independently locked maps, so concurrent
+// operations on different keys rarely contend on the same lock
+type ShardedCache struct {
+	shards []*cacheShard
+}
+
+var _ Cache = (*ShardedCache)(nil)
+
+// NewShardedCache creates a ShardedCache with the given number of shards
+func NewShardedCache(shardCount int) *ShardedCache {
+	if shardCount <= 0 {
+		shardCount = defaultShardCount
+	}
+	shards := make([]*cacheShard, shardCount)
+	for i := range shards {
+		shards[i] = &cacheShard{data: make(map[string]interface{})}
+	}
+	return &ShardedCache{shards: shards}
+}
+
+// shard picks the shard for key with a 32-bit FNV-1a hash, computed inline to avoid
+// allocating a hash.Hash32 on every call
+func (c *ShardedCache) shard(key string) *cacheShard {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+	hash := uint32(offset32)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+	return c.shards[hash%uint32(len(c.shards))]
+}
+
+// Set stores a value in the cache
+func (c *ShardedCache) Set(key string, value interface{}) {
+	shard := c.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	shard.data[key] = value
+}
+
+// Get retrieves a value from the cache
+func (c *ShardedCache) Get(key string) (interface{}, bool) {
+	shard := c.shard(key)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+	value, exists := shard.data[key]
+	return value, exists
+}
+
+// Delete removes a value from the cache
+func (c *ShardedCache) Delete(key string) {
+	shard := c.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	delete(shard.data, key)
+}
+
+// Len returns the number of entries across all shards. Shards are counted one at a time,
+// so the result is approximate while other goroutines are writing
+func (c *ShardedCache) Len() int {
+	total := 0
+	for _, shard := range c.shards {
+		shard.mu.RLock()
+		total += len(shard.data)
+		shard.mu.RUnlock()
+	}
+	return total
+}
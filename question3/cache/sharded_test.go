@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestShardedCache tests basic operations and Len across shards
+func TestShardedCache(t *testing.T) {
+	cache := NewShardedCache(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache.Set("key"+strconv.Itoa(i), i)
+		}(i)
+	}
+	wg.Wait()
+
+	if cache.Len() != 100 {
+		t.Fatalf("expected 100 entries, got %d", cache.Len())
+	}
+	if value, exists := cache.Get("key42"); !exists || value != 42 {
+		t.Errorf("expected 42, got %v", value)
+	}
+
+	cache.Delete("key42")
+	if _, exists := cache.Get("key42"); exists {
+		t.Error("key42 should be deleted")
+	}
+	if cache.Len() != 99 {
+		t.Errorf("expected 99 entries, got %d", cache.Len())
+	}
+}
+
+// benchmarkParallel runs a mixed Set/Get workload (1 write per 4 reads) against c
+func benchmarkParallel(b *testing.B, c Cache) {
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = "key" + strconv.Itoa(i)
+		c.Set(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			if i%5 == 0 {
+				c.Set(key, i)
+			} else {
+				c.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkSimpleCache_Parallel measures the single-lock cache under parallel load
+func BenchmarkSimpleCache_Parallel(b *testing.B) {
+	benchmarkParallel(b, NewSimpleCache())
+}
+
+// BenchmarkShardedCache_Parallel measures the sharded cache under the same load
+func BenchmarkShardedCache_Parallel(b *testing.B) {
+	benchmarkParallel(b, NewShardedCache(32))
+}
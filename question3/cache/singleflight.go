@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+)
+
+// flightCall is an in-flight or completed call shared by every caller of the same key
+type flightCall struct {
+	done    chan struct{}
+	value   interface{}
+	err     error
+	missing bool // set by DoMany when fn returned no value for the key
+}
+
+// flightGroup deduplicates concurrent calls per key, like golang.org/x/sync/singleflight
+// but without pulling in the dependency
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+	async sync.WaitGroup // tracks calls started by DoAsync, RefreshingCache.Close waits on it
+}
+
+// Do runs fn once for all concurrent callers with the same key and returns its result to each of them
+func (g *flightGroup) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	for {
+		call, ok := g.calls[key]
+		if !ok {
+			break
+		}
+		g.mu.Unlock()
+		<-call.done
+		if !call.missing {
+			return call.value, call.err
+		}
+		// a DoMany batch got no value for key, run fn after all
+		g.mu.Lock()
+	}
+	call := g.start(key)
+	g.mu.Unlock()
+
+	g.run(key, call, fn)
+	return call.value, call.err
+}
+
+// DoAsync starts fn in the background unless a call for key is already in flight,
+// returning whether it started one
+func (g *flightGroup) DoAsync(key string, fn func() (interface{}, error)) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.calls[key]; ok {
+		return false
+	}
+	call := g.start(key)
+	g.async.Add(1)
+	go func() {
+		defer g.async.Done()
+		g.run(key, call, fn)
+	}()
+	return true
+}
+
+// start registers a new call for key, the caller must hold g.mu
+func (g *flightGroup) start(key string) *flightCall {
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	call := &flightCall{done: make(chan struct{})}
+	g.calls[key] = call
+	return call
+}
+
+// run executes fn, publishes its result and forgets the call so the next one starts fresh.
+// A panic in fn becomes the call's error, so waiters are released instead of blocking forever
+func (g *flightGroup) run(key string, call *flightCall, fn func() (interface{}, error)) {
+	defer func() {
+		close(call.done)
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+	}()
+	defer recoverLoader(&call.err)
+
+	call.value, call.err = fn()
+}
+
+// callLoader runs a GetOrCompute loader, turning a panic into an error like run does
+func callLoader(fn func() (interface{}, error)) (value interface{}, err error) {
+	defer recoverLoader(&err)
+	return fn()
+}
+
+// callMany runs a DoMany or GetOrComputeMany loader, turning a panic into an error like run does
+func callMany(fn func(keys []string) (map[string]interface{}, error), keys []string) (values map[string]interface{}, err error) {
+	defer recoverLoader(&err)
+	return fn(keys)
+}
+
+// recoverLoader must be deferred, it stores a panic of the loader in err as ErrLoaderPanic
+func recoverLoader(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("%w: %v", ErrLoaderPanic, r)
+	}
+}
+
+// DoMany runs fn once with the keys of keys that no other call is loading, while waiting for the
+// keys that are already in flight, so overlapping batches share their loads. The result holds
+// every key that got a value, the first error of fn or of a waited-for call is returned as well
+func (g *flightGroup) DoMany(keys []string, fn func(keys []string) (map[string]interface{}, error)) (map[string]interface{}, error) {
+	g.mu.Lock()
+	calls := make(map[string]*flightCall, len(keys))
+	var owned []string
+	for _, key := range keys {
+		if _, seen := calls[key]; seen {
+			continue
+		}
+		if call, ok := g.calls[key]; ok {
+			calls[key] = call
+			continue
+		}
+		calls[key] = g.start(key)
+		owned = append(owned, key)
+	}
+	g.mu.Unlock()
+
+	if len(owned) > 0 {
+		values, err := callMany(fn, owned)
+		for _, key := range owned {
+			call := calls[key]
+			value, ok := values[key]
+			call.value, call.missing = value, !ok
+			if !ok {
+				call.err = err // keys fn did return values for succeed even if it failed for others
+			}
+			close(call.done)
+		}
+
+		g.mu.Lock()
+		for _, key := range owned {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+	}
+
+	result := make(map[string]interface{}, len(calls))
+	var firstErr error
+	for key, call := range calls {
+		<-call.done
+		if call.err != nil && firstErr == nil {
+			firstErr = call.err
+		}
+		if call.err == nil && !call.missing {
+			result[key] = call.value
+		}
+	}
+	return result, firstErr
+}
@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// sizedEntry is a cached value with its estimated size
+type sizedEntry struct {
+	key   string
+	value interface{}
+	size  int64
+}
+
+// SizedCache is an LRU cache capped by the total size of its values instead of the entry count
+type SizedCache struct {
+	data     map[string]*list.Element
+	order    *list.List // front is the most recently used entry
+	maxBytes int64
+	size     int64 // sum of the sizes of all stored values
+	sizeOf   func(interface{}) int64
+	onEvict  EvictFunc
+	mu       sync.Mutex
+}
+
+// NewSizedCache creates a SizedCache holding values whose sizes, as estimated by sizeOf,
+// add up to at most maxBytes
+func NewSizedCache(maxBytes int64, sizeOf func(interface{}) int64) *SizedCache {
+	return &SizedCache{
+		data:     make(map[string]*list.Element),
+		order:    list.New(),
+		maxBytes: maxBytes,
+		sizeOf:   sizeOf,
+	}
+}
+
+// Set stores a value as most recently used, then evicts the least recently used entries until
+// the total size fits. A value larger than maxBytes on its own is not stored, and the
+// key's previous value is dropped
+func (c *SizedCache) Set(key string, value interface{}) {
+	size := c.sizeOf(value)
+
+	var evicted []eviction
+	var onEvict EvictFunc // read under the lock, SetOnEvict may run concurrently
+	defer func() { fireEvictions(onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	onEvict = c.onEvict
+
+	if size > c.maxBytes {
+		if elem, exists := c.data[key]; exists {
+			evicted = append(evicted, c.remove(elem, EvictCapacity))
+		}
+		return
+	}
+
+	if elem, exists := c.data[key]; exists {
+		entry := elem.Value.(*sizedEntry)
+		c.size += size - entry.size
+		entry.value, entry.size = value, size
+		c.order.MoveToFront(elem)
+	} else {
+		c.data[key] = c.order.PushFront(&sizedEntry{key: key, value: value, size: size})
+		c.size += size
+	}
+
+	for c.size > c.maxBytes && c.order.Len() > 0 {
+		evicted = append(evicted, c.remove(c.order.Back(), EvictCapacity))
+	}
+}
+
+// Get retrieves a value and marks it as most recently used
+func (c *SizedCache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.data[key]
+	if !exists {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*sizedEntry).value, true
+}
+
+// Delete removes a value from the cache
+func (c *SizedCache) Delete(key string) {
+	var evicted []eviction
+	var onEvict EvictFunc
+	defer func() { fireEvictions(onEvict, evicted) }()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	onEvict = c.onEvict
+
+	if elem, exists := c.data[key]; exists {
+		evicted = append(evicted, c.remove(elem, EvictDeleted))
+	}
+}
+
+// SetOnEvict registers fn to be called when an entry is evicted for capacity or deleted
+func (c *SizedCache) SetOnEvict(fn EvictFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onEvict = fn
+}
+
+// Len returns the number of entries in the cache
+func (c *SizedCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// Size returns the total size of the stored values
+func (c *SizedCache) Size() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.size
+}
+
+// remove unlinks an entry, releases its size and returns it as an eviction with reason.
+// The caller must hold the lock
+func (c *SizedCache) remove(elem *list.Element, reason EvictReason) eviction {
+	entry := c.order.Remove(elem).(*sizedEntry)
+	delete(c.data, entry.key)
+	c.size -= entry.size
+	return eviction{key: entry.key, value: entry.value, reason: reason}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import "testing"
+
+// fixedSize treats every value as an int64 size, so tests control sizes exactly
+func fixedSize(value interface{}) int64 {
+	return value.(int64)
+}
+
+// TestSizedCache_EvictsAtByteLimit tests LRU eviction once the total size passes the limit
+func TestSizedCache_EvictsAtByteLimit(t *testing.T) {
+	cache := NewSizedCache(100, fixedSize)
+
+	cache.Set("a", int64(40))
+	cache.Set("b", int64(40))
+	cache.Set("c", int64(20)) // exactly at the limit, nothing evicted
+	if cache.Len() != 3 || cache.Size() != 100 {
+		t.Fatalf("expected 3 entries and 100 bytes, got %d and %d", cache.Len(), cache.Size())
+	}
+
+	// Touch "a" so "b" is the least recently used
+	cache.Get("a")
+	cache.Set("d", int64(30))
+
+	if _, exists := cache.Get("b"); exists {
+		t.Error("b should have been evicted")
+	}
+	if cache.Size() != 90 {
+		t.Errorf("expected 90 bytes after eviction, got %d", cache.Size())
+	}
+}
+
+// TestSizedCache_UpdateAndDelete tests that overwrites and deletes keep the size accurate
+func TestSizedCache_UpdateAndDelete(t *testing.T) {
+	cache := NewSizedCache(100, fixedSize)
+
+	cache.Set("a", int64(10))
+	cache.Set("a", int64(60))
+	if cache.Size() != 60 {
+		t.Errorf("expected 60 bytes after overwrite, got %d", cache.Size())
+	}
+
+	cache.Delete("a")
+	if cache.Size() != 0 || cache.Len() != 0 {
+		t.Errorf("expected an empty cache, got %d entries and %d bytes", cache.Len(), cache.Size())
+	}
+
+	// A single value over the limit is never kept and doesn't flush the others
+	cache.Set("small", int64(10))
+	cache.Set("huge", int64(150))
+	if _, exists := cache.Get("huge"); exists {
+		t.Error("a value larger than the limit should not be stored")
+	}
+	if cache.Size() != 10 {
+		t.Errorf("expected 10 bytes, got %d", cache.Size())
+	}
+}
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"encoding/gob"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// snapshotEntry is the gob representation of a live TTLCache item
+type snapshotEntry struct {
+	Key       string
+	Value     interface{}
+	Remaining time.Duration // lifetime left at save time
+	TTL       time.Duration // original lifetime, kept for sliding mode
+}
+
+// SaveSnapshot writes all live entries with their remaining TTL to w using encoding/gob.
+// Only gob-encodable values are supported: basic types work out of the box,
+// custom types stored as interface{} must be registered with gob.Register first
+func (c *TTLCache) SaveSnapshot(w io.Writer) error {
+	c.mu.RLock()
+	if c.closed {
+		c.mu.RUnlock()
+		return ErrCacheClosed
+	}
+	now := time.Now()
+	entries := make([]snapshotEntry, 0, len(c.data))
+	for key, item := range c.data {
+		remaining := item.expiration.Sub(now)
+		if remaining <= 0 {
+			continue // already expired, not worth restoring
+		}
+		entries = append(entries, snapshotEntry{
+			Key:       key,
+			Value:     item.value,
+			Remaining: remaining,
+			TTL:       item.ttl,
+		})
+	}
+	c.mu.RUnlock()
+
+	// encode outside the lock so a slow writer does not block the cache
+	return gob.NewEncoder(w).Encode(entries)
+}
+
+// LoadSnapshot reads entries written by SaveSnapshot and stores them with their
+// remaining TTL counted from now. Existing keys with the same name are overwritten
+func (c *TTLCache) LoadSnapshot(r io.Reader) error {
+	var entries []snapshotEntry
+	if err := gob.NewDecoder(r).Decode(&entries); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return ErrCacheClosed
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		c.put(entry.Key, &cacheItem{
+			entryStats: entryStats{createdAt: now},
+			value:      entry.Value,
+			expiration: now.Add(entry.Remaining),
+			ttl:        entry.TTL,
+		})
+	}
+	c.logger.Info("cache snapshot loaded", "count", len(entries))
+	return nil
+}
+
+// loadSnapshotFile loads the snapshot at path, a missing file is not an error
+func (c *TTLCache) loadSnapshotFile(path string) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return c.LoadSnapshot(f)
+}
+
+// saveSnapshotFile writes the snapshot to a temporary file next to path and renames it into place,
+// so a crash mid-write never leaves a truncated snapshot behind
+func (c *TTLCache) saveSnapshotFile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	if err := c.SaveSnapshot(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the latency histogram buckets, a final +Inf bucket is implied
+var latencyBuckets = []time.Duration{
+	time.Microsecond,
+	5 * time.Microsecond,
+	10 * time.Microsecond,
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+}
+
+// latencyHistogram counts durations into fixed buckets with atomic counters, so recording never locks
+type latencyHistogram struct {
+	counts []atomic.Uint64 // one per bucket plus +Inf, not cumulative
+	sum    atomic.Int64    // nanoseconds
+}
+
+func newLatencyHistogram() *latencyHistogram {
+	return &latencyHistogram{counts: make([]atomic.Uint64, len(latencyBuckets)+1)}
+}
+
+// observe records the time elapsed since start
+func (h *latencyHistogram) observe(start time.Time) {
+	d := time.Since(start)
+	i := 0
+	for i < len(latencyBuckets) && d > latencyBuckets[i] {
+		i++
+	}
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// snapshot returns the histogram with Prometheus-style cumulative bucket counts
+func (h *latencyHistogram) snapshot() Histogram {
+	snap := Histogram{
+		Buckets: latencyBuckets,
+		Counts:  make([]uint64, len(h.counts)),
+		Sum:     time.Duration(h.sum.Load()),
+	}
+	var cumulative uint64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		snap.Counts[i] = cumulative
+	}
+	snap.Count = cumulative
+	return snap
+}
+
+// Histogram is a point-in-time copy of a latency histogram. Counts[i] is the number of
+// observations <= Buckets[i], the last entry of Counts is the +Inf bucket and equals Count
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+}
+
+// CacheStats holds the metrics collected by a TTLCache
+type CacheStats struct {
+	// Hits and Misses count Get and GetMany lookups since the cache was created, Clear keeps them
+	Hits   uint64
+	Misses uint64
+	// Evictions counts every removed entry, whether by expiry, a delete or Clear,
+	// i.e. the entries OnEvict is called for
+	Evictions uint64
+	// GetLatency and LoadLatency are only populated when the cache was created with RecordLatency.
+	// GetLatency has one observation per Get, including the lookup GetOrCompute starts with,
+	// LoadLatency one per loader call of GetOrCompute or GetOrComputeMany
+	GetLatency  Histogram
+	LoadLatency Histogram
+}
+
+// Stats returns the cache's collected metrics
+func (c *TTLCache) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+	}
+	if c.getLatency != nil {
+		stats.GetLatency = c.getLatency.snapshot()
+		stats.LoadLatency = c.loadLatency.snapshot()
+	}
+	return stats
+}
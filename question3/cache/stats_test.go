@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTTLCache_GetLatencyHistogram tests that every Get is counted and the buckets are cumulative
+func TestTTLCache_GetLatencyHistogram(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Minute, RecordLatency: true})
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("key", "value")
+	for i := 0; i < 100; i++ {
+		cache.Get("key")
+		cache.Get("missing")
+	}
+
+	hist := cache.Stats().GetLatency
+	if hist.Count != 200 {
+		t.Fatalf("expected 200 observations, got %d", hist.Count)
+	}
+	if len(hist.Counts) != len(hist.Buckets)+1 {
+		t.Fatalf("expected %d counts, got %d", len(hist.Buckets)+1, len(hist.Counts))
+	}
+	for i := 1; i < len(hist.Counts); i++ {
+		if hist.Counts[i] < hist.Counts[i-1] {
+			t.Errorf("bucket counts must be cumulative, got %v", hist.Counts)
+		}
+	}
+	if hist.Counts[len(hist.Counts)-1] != hist.Count {
+		t.Errorf("+Inf bucket should equal the total count, got %v", hist.Counts)
+	}
+	if hist.Sum <= 0 || hist.Sum > time.Second {
+		t.Errorf("unexpected total latency %v", hist.Sum)
+	}
+
+	// Disabled by default
+	plain := NewTTLCache(time.Minute)
+	defer plain.Stop()
+	plain.Get("key")
+	if plain.Stats().GetLatency.Count != 0 {
+		t.Error("latency should not be recorded unless enabled")
+	}
+}
+
+// TestTTLCache_LoadLatencyHistogram tests that loader calls are recorded apart from Get and that
+// a GetOrCompute miss adds a single Get observation
+func TestTTLCache_LoadLatencyHistogram(t *testing.T) {
+	cache := NewTTLCacheWithOptions(TTLCacheOptions{DefaultTTL: time.Minute, RecordLatency: true})
+	defer cache.Stop()
+
+	load := func() (interface{}, error) {
+		time.Sleep(2 * time.Millisecond)
+		return 1, nil
+	}
+	cache.GetOrCompute("a", load) // miss, loads
+	cache.GetOrCompute("a", load) // hit
+	cache.GetOrComputeMany([]string{"a", "b"}, func(missing []string) (map[string]interface{}, error) {
+		return map[string]interface{}{"b": 2}, nil
+	})
+
+	stats := cache.Stats()
+	if stats.GetLatency.Count != 2 {
+		t.Errorf("expected 2 Get observations, got %d", stats.GetLatency.Count)
+	}
+	if stats.LoadLatency.Count != 2 {
+		t.Errorf("expected 2 load observations, got %d", stats.LoadLatency.Count)
+	}
+	if stats.LoadLatency.Sum < 2*time.Millisecond {
+		t.Errorf("expected the slow loader in the load latency, got %v", stats.LoadLatency.Sum)
+	}
+}
+
+// TestTTLCache_HitMissStats tests the lookup and eviction counters
+func TestTTLCache_HitMissStats(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	cache.SetWithDefaultTTL("a", 1)
+	cache.SetWithDefaultTTL("b", 2)
+	cache.Get("a")
+	cache.Get("a")
+	cache.Get("missing")
+	cache.GetMany([]string{"a", "b", "nope"})
+	cache.Peek("a") // peeks are not lookups
+	cache.Delete("b")
+
+	stats := cache.Stats()
+	if stats.Hits != 4 || stats.Misses != 2 || stats.Evictions != 1 {
+		t.Errorf("expected 4 hits, 2 misses, 1 eviction, got %+v", stats)
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+// TieredCache puts a small LRU hot tier (L1, no TTL) in front of a larger TTLCache (L2).
+// Because L1 has no expiration it can keep serving a value after it expired in L2,
+// size L1 small enough that this staleness is acceptable
+type TieredCache struct {
+	l1 *LRUCache
+	l2 *TTLCache
+}
+
+var _ Cache = (*TieredCache)(nil)
+
+// NewTieredCache creates a TieredCache from an existing L1 and L2
+func NewTieredCache(l1 *LRUCache, l2 *TTLCache) *TieredCache {
+	return &TieredCache{l1: l1, l2: l2}
+}
+
+// Set writes the value through to both tiers, L2 uses its default TTL
+func (c *TieredCache) Set(key string, value interface{}) {
+	c.l1.Set(key, value)
+	c.l2.SetWithDefaultTTL(key, value)
+}
+
+// Get checks L1 first, then L2. An L2 hit is promoted into L1.
+// Entries evicted from L1 stay in L2
+func (c *TieredCache) Get(key string) (interface{}, bool) {
+	if value, exists := c.l1.Get(key); exists {
+		return value, true
+	}
+
+	value, exists := c.l2.Get(key)
+	if !exists {
+		return nil, false
+	}
+	c.l1.Set(key, value)
+	return value, true
+}
+
+// Delete removes the value from both tiers
+func (c *TieredCache) Delete(key string) {
+	c.l1.Delete(key)
+	c.l2.Delete(key)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTieredCache_WriteThrough tests that Set writes to both tiers
+func TestTieredCache_WriteThrough(t *testing.T) {
+	l1 := NewLRUCache(2)
+	l2 := NewTTLCache(time.Minute)
+	defer l2.Stop()
+	cache := NewTieredCache(l1, l2)
+
+	cache.Set("user:1", "Alice")
+
+	if _, exists := l1.Get("user:1"); !exists {
+		t.Error("user:1 should be written to L1")
+	}
+	if _, exists := l2.Get("user:1"); !exists {
+		t.Error("user:1 should be written to L2")
+	}
+}
+
+// TestTieredCache_PromotesL2Hit tests that an L1 eviction leaves L2 intact and a later hit is promoted
+func TestTieredCache_PromotesL2Hit(t *testing.T) {
+	l1 := NewLRUCache(1)
+	l2 := NewTTLCache(time.Minute)
+	defer l2.Stop()
+	cache := NewTieredCache(l1, l2)
+
+	cache.Set("a", 1)
+	cache.Set("b", 2) // evicts a from L1 only
+
+	if _, exists := l1.Get("a"); exists {
+		t.Fatal("a should have been evicted from L1")
+	}
+
+	if value, exists := cache.Get("a"); !exists || value != 1 {
+		t.Fatalf("expected a=1 from L2, got %v %v", value, exists)
+	}
+	if _, exists := l1.Get("a"); !exists {
+		t.Error("a should be promoted into L1 after an L2 hit")
+	}
+}
@@ -0,0 +1,57 @@
+package cache
+
+import "time"
+
+// CacheEntry is a value to preload into a TTLCache with its own TTL, zero uses the default TTL
+type CacheEntry struct {
+	Value interface{}
+	TTL   time.Duration
+}
+
+// Warm bulk-loads items under a single lock, merging them into the current contents.
+// Warming an empty cache allocates the map at its final size up front
+func (c *SimpleCache) Warm(items map[string]interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	if len(c.data) == 0 {
+		c.data = make(map[string]*simpleItem, len(items))
+	}
+	now := time.Now()
+	for key, value := range items {
+		c.data[key] = newSimpleItem(value, now)
+	}
+}
+
+// Warm bulk-loads items with their TTLs under a single lock, merging them into the current contents.
+// Warming an empty cache allocates the map at its final size up front
+func (c *TTLCache) Warm(items map[string]CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	if len(c.data) == 0 {
+		c.data = make(map[string]*cacheItem, len(items))
+	}
+	now := time.Now()
+	for key, entry := range items {
+		ttl := entry.TTL
+		if ttl <= 0 {
+			ttl = c.defaultTTL
+		}
+		c.put(key, &cacheItem{
+			entryStats: entryStats{createdAt: now},
+			value:      entry.Value,
+			expiration: c.expiresAt(now, ttl),
+			ttl:        ttl,
+		})
+	}
+	c.logger.Debug("cache warm", "count", len(items))
+}
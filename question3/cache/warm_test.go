@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestTTLCache_Warm tests that every entry is loaded with its TTL and readers never see a partial warm-up
+func TestTTLCache_Warm(t *testing.T) {
+	cache := NewTTLCache(time.Minute)
+	defer cache.Stop()
+
+	const n = 10000
+	items := make(map[string]CacheEntry, n)
+	for i := 0; i < n; i++ {
+		items[strconv.Itoa(i)] = CacheEntry{Value: i}
+	}
+	items["short"] = CacheEntry{Value: "short", TTL: 10 * time.Millisecond}
+
+	// Count the map under the read lock rather than with Keys, which hides "short" once it
+	// expires during a slow (e.g. -race) warm-up. With a single write lock the reader sees
+	// either nothing or everything
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	partial := 0
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			cache.mu.RLock()
+			count := len(cache.data)
+			cache.mu.RUnlock()
+			if count != 0 && count != n+1 {
+				partial++
+			}
+		}
+	}()
+
+	cache.Warm(items)
+	close(stop)
+	wg.Wait()
+
+	if partial != 0 {
+		t.Errorf("readers observed a partially warmed cache %d times", partial)
+	}
+	if value, exists := cache.Get("42"); !exists || value != 42 {
+		t.Errorf("expected 42, got %v", value)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, exists := cache.Get("short"); exists {
+		t.Error("short should expire with its own TTL")
+	}
+	if _, exists := cache.Get("0"); !exists {
+		t.Error("entries without a TTL should use the default TTL")
+	}
+}
+
+// TestSimpleCache_Warm tests merging into existing contents
+func TestSimpleCache_Warm(t *testing.T) {
+	cache := NewSimpleCache()
+	cache.Set("existing", 1)
+	cache.Warm(map[string]interface{}{"a": 2, "existing": 3})
+
+	if len(cache.Keys()) != 2 {
+		t.Errorf("expected 2 keys, got %v", cache.Keys())
+	}
+	if value, _ := cache.Get("existing"); value != 3 {
+		t.Errorf("warm should overwrite existing keys, got %v", value)
+	}
+}